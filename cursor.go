@@ -1,36 +1,92 @@
 package excel
 
 import (
+	"context"
+	"math"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/360EntSecGroup-Skylar/excelize/v2"
 	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure"
+	"github.com/yueja/go-excel-orm/structure/tag"
 )
 
 // AfterFieldHandler 当一个字段被解析后, 会触发本回调
 type AfterFieldHandler func(header string, valueStr string, value interface{}, err error, col int, row int)
 
+// ProgressHandler 进度回调, processed 为已处理的数据行数, total 为数据总行数, 未知时为 -1
+//
+// 解码时的 total 为表头之后 sheet 的所有行数, 包括空白行与表格结尾之后的行, 在设置回调时通过额外的一次遍历获取
+type ProgressHandler func(processed int, total int)
+
+// BlankRowPolicy 遇到所有单元格均为空白的行时的处理策略
+type BlankRowPolicy int
+
+const (
+	// BlankRowKeep 空白行与其他行相同, 解码为零值元素
+	BlankRowKeep BlankRowPolicy = iota
+	// BlankRowSkip 跳过空白行, 继续迭代后续行
+	BlankRowSkip
+	// BlankRowStop 视空白行为表格的结尾, 停止迭代
+	BlankRowStop
+)
+
+// EndOfTableFunc 判断一行是否为表格的结尾, 如合计行, 返回 true 时该行及其后的行都不会被迭代
+//
+// cols 为该行所有单元格的字符串, 末尾的空单元格可能不存在
+type EndOfTableFunc func(cols []string) bool
+
 // Cursor 按行解析 excel 的迭代器
 type Cursor struct {
+	ef                *excelize.File                       // 正在解析的 excel 文件
+	sheetName         string                               // 正在解析的 sheet 名
 	headerIndex       map[string]int                       // excel 文件中表头与列位置的映射
 	rows              *excelize.Rows                       // excel 行迭代器
 	typeParsers       map[reflect.Type]internalFieldParser // 类型解析器, 其优先级低于 tagParsers
 	tagParsers        map[string]internalFieldParser       // tag 解析器, 其优先级高于 typeParsers
+	enums             map[string]*enum                     // 已注册的枚举, 通过 tag 的 enum 选项引用
 	rowNow            int                                  // 当前迭代到的行, 从 0 开始
+	rowOffset         int                                  // 数据行之前的行数, rowNow + rowOffset 即 excel 中的行号
 	afterFieldHandler AfterFieldHandler                    // 当每个字段完成解析, 无论是否报错, 都会触发此回调
+	errorPolicy       ErrorPolicy                          // 字段解析出错时的处理策略
+	date1904          bool                                 // excel 是否使用 1904 日期系统
+	fieldsCache       map[reflect.Type][]fieldDecoder      // 已编译的字段解码信息, 注册解析器时失效
+	totalRows         int                                  // 数据总行数, 未知时为 -1
+	progressHandler   ProgressHandler                      // 每处理完一行数据都会触发此回调
+	parallelism       int                                  // 并行解码的 worker 数量, 不大于 1 时逐行解码
+	headerNormalizer  HeaderNormalizer                     // 查找表头前用于规范化 tag, 为 nil 时不处理
+	strict            bool                                 // 严格模式, excel 中的每个表头都必须有对应的字段
+	blankRowPolicy    BlankRowPolicy                       // 遇到空白行时的处理策略
+	endOfTable        EndOfTableFunc                       // 判断表格结尾的函数, 为 nil 时迭代到 sheet 的最后一行
+	cols              []string                             // 当前行所有单元格的字符串
+	colsErr           error                                // 读取当前行失败的原因
+	ended             bool                                 // 已经到达表格的结尾
+	pending           []rowJob                             // 并行解码提前读取但未处理的行, Next 优先按顺序返回
 }
 
 func newCursor(
+	ef *excelize.File,
+	sheetName string,
 	headerIndex map[string]int,
 	rows *excelize.Rows,
+	rowOffset int,
 ) (
 	c *Cursor,
 ) {
 	c = &Cursor{
+		ef:          ef,
+		sheetName:   sheetName,
 		headerIndex: headerIndex,
+		rowOffset:   rowOffset,
 		rows:        rows,
 		typeParsers: make(map[reflect.Type]internalFieldParser),
 		tagParsers:  make(map[string]internalFieldParser),
+		enums:       make(map[string]*enum),
+		fieldsCache: make(map[reflect.Type][]fieldDecoder),
+		totalRows:   -1,
 	}
 	c.initTypeParsers()
 	return
@@ -41,24 +97,153 @@ func (c *Cursor) OnFieldHandled(h AfterFieldHandler) {
 	c.afterFieldHandler = h
 }
 
+// SetErrorPolicy 设置字段解析出错时的处理策略, 默认为 ErrorPolicyFailFast
+func (c *Cursor) SetErrorPolicy(p ErrorPolicy) {
+	c.errorPolicy = p
+}
+
+// OnProgress 设置进度回调, 每处理完一行数据都会触发
+//
+// 首次设置回调时遍历一次 sheet 以获取数据总行数, 不应在解码过程中调用
+func (c *Cursor) OnProgress(h ProgressHandler) {
+	c.progressHandler = h
+	if h != nil && c.totalRows < 0 {
+		c.totalRows = c.countTotalRows()
+	}
+}
+
+// SetParallelism 设置并行解码的 worker 数量, 不大于 1 时逐行解码, 默认为 0
+//
+// 并行模式下由单个 goroutine 按顺序读取原始行, n 个 worker 并发执行字段解析, 解码结果仍按原始行的顺序返回,
+// 错误也归属于正确的行号. 此时解析器与 AfterFieldHandler 会被多个 goroutine 并发调用, 必须是并发安全的,
+// AfterFieldHandler 的触发顺序也不再与行的顺序一致. 对 Scan 无效
+func (c *Cursor) SetParallelism(n int) {
+	c.parallelism = n
+}
+
+// SetStrict 设置严格模式, 严格模式下 excel 中存在没有对应字段的表头时, 解码返回 UnknownHeadersError
+func (c *Cursor) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+// SetBlankRowPolicy 设置遇到空白行时的处理策略, 默认为 BlankRowKeep
+func (c *Cursor) SetBlankRowPolicy(p BlankRowPolicy) {
+	c.blankRowPolicy = p
+}
+
+// SetEndOfTable 设置判断表格结尾的函数, 到达结尾后 Next 返回 false
+func (c *Cursor) SetEndOfTable(fn EndOfTableFunc) {
+	c.endOfTable = fn
+}
+
 // Next 如果还有下个元素, 返回 true
+//
+// 按照 BlankRowPolicy 跳过空白行, 到达空白行或 EndOfTableFunc 判定的表格结尾时返回 false
 func (c *Cursor) Next() bool {
-	c.rowNow++
-	return c.rows.Next()
+	if len(c.pending) > 0 {
+		job := c.pending[0]
+		c.pending = c.pending[1:]
+		c.rowNow, c.cols, c.colsErr = job.rowNow, job.cols, job.colsErr
+		return true
+	}
+	if c.ended {
+		return false
+	}
+
+	// 没有下一行时, 当前行保持为最后返回的行
+	rowNow := c.rowNow
+	for {
+		c.rowNow++
+		if !c.rows.Next() {
+			c.ended = true
+			c.rowNow = rowNow
+			return false
+		}
+
+		// 读取失败时由解码报告错误
+		c.cols, c.colsErr = c.rows.Columns()
+		if c.colsErr != nil {
+			c.colsErr = errors.WithStack(c.colsErr)
+			return true
+		}
+
+		if c.endOfTable != nil && c.endOfTable(c.cols) {
+			c.ended = true
+			c.rowNow = rowNow
+			return false
+		}
+		if c.blankRowPolicy == BlankRowKeep || !isBlankRow(c.cols) {
+			return true
+		}
+		if c.blankRowPolicy == BlankRowStop {
+			c.ended = true
+			c.rowNow = rowNow
+			return false
+		}
+	}
+}
+
+// columns 获取当前行所有单元格的字符串
+func (c *Cursor) columns() (cols []string, err error) {
+	return c.cols, c.colsErr
+}
+
+// isBlankRow 判断一行的所有单元格是否均为空白
+func isBlankRow(cols []string) bool {
+	for _, col := range cols {
+		if strings.TrimSpace(col) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Row 当前行在 excel 中的行号, 从 1 开始
+func (c *Cursor) Row() int {
+	return c.rowNow + c.rowOffset
+}
+
+// Scan 将当前行解码到 elemPtr 指向的元素
+//
+// elemPtr 中没有对应单元格的字段保持原值. 单元格解析出错时返回该行的 DecodeErrors
+func (c *Cursor) Scan(elemPtr interface{}) (err error) {
+	elemType, err := getElemTypeOfElem(elemPtr)
+	if err != nil {
+		return
+	}
+	elemPtrValue := reflect.ValueOf(elemPtr)
+	if elemPtrValue.IsNil() {
+		err = errors.WithStack(ErrElemDecodedIsNotAddressablePtr)
+		return
+	}
+
+	fields, err := c.getFields(elemType)
+	if err != nil {
+		return
+	}
+	rowErrs, err := c.decodeRow(fields, elemPtrValue)
+	if err != nil {
+		return
+	}
+	if len(rowErrs) > 0 {
+		err = rowErrs
+	}
+	return
 }
 
 // Decode 解码数据到变量, elem 应是目标元素的指针
 func (c *Cursor) Decode(elems interface{}) (err error) {
+	return c.DecodeContext(context.Background(), elems)
+}
+
+// DecodeContext 与 Decode 相同, 每行解码前检查 ctx 是否已取消, 已取消时返回 ctx.Err()
+func (c *Cursor) DecodeContext(ctx context.Context, elems interface{}) (err error) {
 	// 获取解码的目标元素类型
 	elemType, err := getElemTypeOfElems(elems)
 	if err != nil {
 		return
 	}
 
-	// 获取目标类型的 tag 索引
-	tagIndex := buildTagIndex(elems)
-	tags := getTags(elems)
-
 	// 获取可访问的目标指针
 	elemsPtrValue := reflect.ValueOf(elems)
 	if elemsPtrValue.Kind() != reflect.Ptr || elemsPtrValue.IsNil() {
@@ -67,24 +252,11 @@ func (c *Cursor) Decode(elems interface{}) (err error) {
 	}
 	elemsValue := elemsPtrValue.Elem()
 
-	// 迭代解析
-	for c.Next() {
-		// 从 excel 获取本行数据
-		var cols []string
-		cols, err = c.rows.Columns()
-		if err != nil {
-			err = errors.WithStack(err)
-			break
-		}
-
-		elemPtr := reflect.New(elemType)
-
-		// 组装结构体
-		err = c.buildOneElem(cols, tags, tagIndex, c.headerIndex, elemType, elemPtr)
-
-		// 将新组装的元素追加到 slice 中
+	// 迭代解析, 将新组装的元素追加到 slice 中
+	err = c.decodeEach(ctx, elemType, math.MaxInt, func(row int, elemPtr reflect.Value) error {
 		elemsValue = reflect.Append(elemsValue, elemPtr.Elem())
-	}
+		return nil
+	})
 
 	// 回写 slice 指针
 	elemsPtrValue.Elem().Set(elemsValue)
@@ -94,16 +266,17 @@ func (c *Cursor) Decode(elems interface{}) (err error) {
 
 // DecodeMany 解码数据到变量, elem 应是目标元素的 slice/array 的指针
 func (c *Cursor) DecodeMany(elems interface{}, limit int) (count int, err error) {
+	return c.DecodeManyContext(context.Background(), elems, limit)
+}
+
+// DecodeManyContext 与 DecodeMany 相同, 每行解码前检查 ctx 是否已取消, 已取消时返回 ctx.Err()
+func (c *Cursor) DecodeManyContext(ctx context.Context, elems interface{}, limit int) (count int, err error) {
 	// 获取解码的目标元素类型
 	elemType, err := getElemTypeOfElems(elems)
 	if err != nil {
 		return
 	}
 
-	// 获取目标类型的 tag 索引
-	tagIndex := buildTagIndex(elems)
-	tags := getTags(elems)
-
 	// 获取可访问的目标指针
 	elemsPtrValue := reflect.ValueOf(elems)
 	if elemsPtrValue.Kind() != reflect.Ptr || elemsPtrValue.IsNil() {
@@ -116,36 +289,282 @@ func (c *Cursor) DecodeMany(elems interface{}, limit int) (count int, err error)
 		elemsValue = elemsValue.Slice(0, 0)
 	}
 
-	// 迭代解析
-	for count < limit && c.Next() {
-		// 从 excel 获取本行数据
-		var cols []string
-		cols, err = c.rows.Columns()
-		if err != nil {
-			err = errors.WithStack(err)
-			break
+	// 迭代解析, 将新组装的元素追加到 slice 中
+	err = c.decodeEach(ctx, elemType, limit, func(row int, elemPtr reflect.Value) error {
+		elemsValue = reflect.Append(elemsValue, elemPtr.Elem())
+		count++
+		return nil
+	})
+
+	// 回写 slice 指针
+	elemsPtrValue.Elem().Set(elemsValue)
+
+	return
+}
+
+// decodeEach 迭代解码最多 limit 个元素, 并按行的顺序将其回调给 fn, row 为该行在 excel 中的行号
+//
+// 被 errorPolicy 丢弃的行不计入 limit. fn 返回错误时停止迭代并原样返回该错误,
+// 按照 errorPolicy 被收集的错误在迭代结束后以 DecodeErrors 返回
+func (c *Cursor) decodeEach(
+	ctx context.Context,
+	elemType reflect.Type,
+	limit int,
+	fn func(row int, elemPtr reflect.Value) error,
+) (
+	err error,
+) {
+	// 获取目标类型的字段解码信息
+	fields, err := c.getFields(elemType)
+	if err != nil {
+		return
+	}
+
+	var decodeErrs DecodeErrors
+	if c.parallelism > 1 {
+		err = c.decodeParallel(ctx, elemType, fields, limit, &decodeErrs, fn)
+	} else {
+		err = c.decodeSequential(ctx, elemType, fields, limit, &decodeErrs, fn)
+	}
+
+	if err == nil && len(decodeErrs) > 0 {
+		err = decodeErrs
+	}
+
+	return
+}
+
+// decodeSequential 在当前 goroutine 中逐行解码, 参数含义与 decodeEach 相同
+func (c *Cursor) decodeSequential(
+	ctx context.Context,
+	elemType reflect.Type,
+	fields []fieldDecoder,
+	limit int,
+	decodeErrs *DecodeErrors,
+	fn func(row int, elemPtr reflect.Value) error,
+) (
+	err error,
+) {
+	for count := 0; count < limit; {
+		// 在前进之前检查 ctx, 使取消时迭代器停留在最后处理的行
+		err = contextErr(ctx)
+		if err != nil || !c.Next() {
+			return
 		}
 
-		// 组装结构体
 		elemPtr := reflect.New(elemType)
-		err = c.buildOneElem(cols, tags, tagIndex, c.headerIndex, elemType, elemPtr)
+		var rowErrs DecodeErrors
+		rowErrs, err = c.decodeRow(fields, elemPtr)
+		if err != nil {
+			return
+		}
+		var keep bool
+		keep, err = c.applyErrorPolicy(rowErrs, decodeErrs)
+		if err != nil {
+			return
+		}
+		if !keep {
+			continue
+		}
 
-		// 将新组装的元素追加到 slice 中
-		elemsValue = reflect.Append(elemsValue, elemPtr.Elem())
+		err = fn(c.Row(), elemPtr)
+		if err != nil {
+			return
+		}
 		count++
 	}
 
-	// 回写 slice 指针
-	elemsPtrValue.Elem().Set(elemsValue)
+	return
+}
+
+// applyErrorPolicy 按照 errorPolicy 处理一行的单元格错误
+//
+// keep 为 false 表示该行应被丢弃; 需要收集的错误会被追加到 decodeErrs,
+// 需要立即中止解码的错误通过 err 返回
+func (c *Cursor) applyErrorPolicy(rowErrs DecodeErrors, decodeErrs *DecodeErrors) (keep bool, err error) {
+	if len(rowErrs) == 0 {
+		keep = true
+		return
+	}
+
+	switch c.errorPolicy {
+	case ErrorPolicySkipRow:
+		*decodeErrs = append(*decodeErrs, rowErrs...)
+	case ErrorPolicyKeepZero:
+		*decodeErrs = append(*decodeErrs, rowErrs...)
+		keep = true
+	default:
+		err = append(*decodeErrs, rowErrs...)
+	}
+
+	return
+}
+
+// DecodeBatches 按批次解码数据并回调 fn, 直到数据全部解码完成, 不受 maxDecodeAllCount 限制
+//
+// elemsPtr 应是目标元素的 slice 的指针, 每个批次都会被解码到新的 slice 中, 在 fn 中可以安全地持有 batch.
+// batch 的类型与 elemsPtr 指向的 slice 相同, 除最后一批外均包含 batchSize 个元素;
+// firstRow、lastRow 为该批次读取的第一行与最后一行在 excel 中的行号, 被跳过的空白行与被 errorPolicy 丢弃的行也在此范围内.
+// fn 返回错误时停止迭代并原样返回该错误. 单元格错误按 errorPolicy 处理,
+// ErrorPolicyFailFast 下出错的批次不会触发回调, 其他策略下被收集的错误在迭代结束后以 DecodeErrors 返回
+func (c *Cursor) DecodeBatches(
+	elemsPtr interface{},
+	batchSize int,
+	fn func(batch interface{}, firstRow int, lastRow int) error,
+) (
+	err error,
+) {
+	return c.DecodeBatchesContext(context.Background(), elemsPtr, batchSize, fn)
+}
+
+// DecodeBatchesContext 与 DecodeBatches 相同, 每行解码前检查 ctx 是否已取消, 已取消时返回 ctx.Err()
+func (c *Cursor) DecodeBatchesContext(
+	ctx context.Context,
+	elemsPtr interface{},
+	batchSize int,
+	fn func(batch interface{}, firstRow int, lastRow int) error,
+) (
+	err error,
+) {
+	if batchSize <= 0 {
+		err = errors.WithMessagef(ErrBatchSizeInvalid, "but %d", batchSize)
+		err = errors.WithStack(err)
+		return
+	}
+	_, err = getElemTypeOfElems(elemsPtr)
+	if err != nil {
+		return
+	}
+	elemsPtrValue := reflect.ValueOf(elemsPtr)
+	if elemsPtrValue.IsNil() {
+		err = errors.WithStack(ErrElemDecodedIsNotAddressablePtr)
+		return
+	}
+	elemsValue := elemsPtrValue.Elem()
+
+	var decodeErrs DecodeErrors
+	for {
+		firstRow := c.Row() + 1
+
+		// 每个批次使用新的 slice, 避免回调持有的 batch 被下个批次覆盖
+		elemsValue.Set(reflect.Zero(elemsValue.Type()))
+
+		var count int
+		count, err = c.DecodeManyContext(ctx, elemsPtr, batchSize)
+		var batchErrs DecodeErrors
+		if err != nil && (c.errorPolicy == ErrorPolicyFailFast || !errors.As(err, &batchErrs)) {
+			return
+		}
+		decodeErrs = append(decodeErrs, batchErrs...)
+		err = nil
+
+		if count == 0 {
+			break
+		}
+		err = fn(elemsValue.Interface(), firstRow, c.Row())
+		if err != nil {
+			return
+		}
+		if count < batchSize {
+			// 数据已经读完
+			break
+		}
+	}
+
+	if len(decodeErrs) > 0 {
+		err = decodeErrs
+	}
+
+	return
+}
+
+// Each 逐行解码并回调 fn, fn 返回错误时停止迭代并原样返回该错误
+//
+// elemPtr 为目标元素的指针, 每行解码前会被重置为零值并复用, 以保持内存占用恒定, 如需保留元素请在 fn 中复制.
+// row 为该行在 excel 中的行号. 单元格错误按 errorPolicy 处理, 被收集的错误在迭代结束后以 DecodeErrors 返回
+func (c *Cursor) Each(elemPtr interface{}, fn func(row int, elemPtr interface{}) error) (err error) {
+	return c.EachContext(context.Background(), elemPtr, fn)
+}
+
+// EachContext 与 Each 相同, 每行解码前检查 ctx 是否已取消, 已取消时返回 ctx.Err()
+func (c *Cursor) EachContext(
+	ctx context.Context,
+	elemPtr interface{},
+	fn func(row int, elemPtr interface{}) error,
+) (
+	err error,
+) {
+	elemType, err := getElemTypeOfElem(elemPtr)
+	if err != nil {
+		return
+	}
+	elemPtrValue := reflect.ValueOf(elemPtr)
+	if elemPtrValue.IsNil() {
+		err = errors.WithStack(ErrElemDecodedIsNotAddressablePtr)
+		return
+	}
+
+	// 迭代解析, 将解码结果复制到 elemPtr 后回调
+	err = c.decodeEach(ctx, elemType, math.MaxInt, func(row int, elem reflect.Value) error {
+		elemPtrValue.Elem().Set(elem.Elem())
+		return fn(row, elemPtr)
+	})
 
 	return
 }
 
 // getFieldParser 获取字段解析器, 优先使用 tag 解析器, 如果 tag 解析器不存在, 则使用类型解析器
-func (c *Cursor) getFieldParser(excelTag string, t reflect.Type) (parser internalFieldParser, err error) {
+//
+// tag 中指定了 enum 选项时, 使用该枚举解析; 时间类型字段的 tag 中指定了 layout 选项时, 使用该 layout 解析.
+// 两者的优先级依次介于 tag 解析器与类型解析器之间
+func (c *Cursor) getFieldParser(excelTag string, t reflect.Type, opts tag.Options) (parser internalFieldParser, err error) {
 	parser, err = c.getTagParser(excelTag)
-	if errors.Is(err, ErrTagParserNotFound) {
-		parser, err = c.getTypeParser(t)
+	if !errors.Is(err, ErrTagParserNotFound) {
+		return
+	}
+
+	e, found, err := getEnum(c.enums, opts)
+	if found {
+		if err != nil {
+			return
+		}
+		parser = e.parser(t)
+		return
+	}
+
+	if layout, ok := opts.Get("layout"); ok {
+		var found bool
+		parser, found = c.getLayoutParser(t, layout)
+		if found {
+			err = nil
+			return
+		}
+	}
+
+	parser, err = c.getTypeParser(t)
+	return
+}
+
+// getLayoutParser 获取按 layout 解析时间的解析器, t 不是时间类型时 found 为 false
+func (c *Cursor) getLayoutParser(t reflect.Type, layout string) (parser internalFieldParser, found bool) {
+	switch {
+	case t == timeType:
+		parser = fieldParser2internalFieldParser(c.timeParser(layout))
+		found = true
+	case t.Kind() == reflect.Ptr:
+		parser, found = c.getLayoutParser(t.Elem(), layout)
+		if found {
+			parser = elemParser2ptrParser(parser, t)
+		}
+	}
+	return
+}
+
+// timeParser 生成时间解析器, 解析时使用 excel 文件的日期系统
+func (c *Cursor) timeParser(layout string) (parser FieldParser) {
+	parser = func(valueStr string, col int, row int) (value interface{}, err error) {
+		value, err = str2time(valueStr, layout, c.date1904)
+		return
 	}
 	return
 }
@@ -159,10 +578,26 @@ func (c *Cursor) RegisterTypeParser(elem interface{}, parser FieldParser) {
 
 func (c *Cursor) registerTypeParser(t reflect.Type, parser internalFieldParser) {
 	c.typeParsers[t] = parser
+	c.fieldsCache = make(map[reflect.Type][]fieldDecoder)
 }
 
 func (c *Cursor) getTypeParser(t reflect.Type) (parser internalFieldParser, err error) {
 	parser, ok := c.typeParsers[t]
+	if ok {
+		return
+	}
+
+	// 没有注册类型解析器时, 使用类型自身实现的解码接口
+	parser, ok = getUnmarshalerParser(t)
+	if !ok && t.Kind() == reflect.Ptr {
+		// 指针类型复用其指向类型的解析器
+		parser, err = c.getTypeParser(t.Elem())
+		if err != nil {
+			return
+		}
+		parser = elemParser2ptrParser(parser, t)
+		return
+	}
 	if !ok {
 		err = errors.WithMessagef(
 			ErrTypeParserNotFound,
@@ -181,8 +616,15 @@ func (c *Cursor) RegisterTagParser(excelTag string, parser FieldParser) {
 	c.registerTagParser(excelTag, internalParser)
 }
 
+// registerEnum 注册枚举
+func (c *Cursor) registerEnum(e *enum) {
+	c.enums[e.name] = e
+	c.fieldsCache = make(map[reflect.Type][]fieldDecoder)
+}
+
 func (c *Cursor) registerTagParser(excelTag string, parser internalFieldParser) {
 	c.tagParsers[excelTag] = parser
+	c.fieldsCache = make(map[reflect.Type][]fieldDecoder)
 }
 
 func (c *Cursor) getTagParser(excelTag string) (parser internalFieldParser, err error) {
@@ -194,55 +636,301 @@ func (c *Cursor) getTagParser(excelTag string) (parser internalFieldParser, err
 	return
 }
 
+// decodeRow 读取当前行并解码到 elemPtr 指向的元素, rowErrs 为该行所有的单元格错误
+func (c *Cursor) decodeRow(fields []fieldDecoder, elemPtr reflect.Value) (rowErrs DecodeErrors, err error) {
+	// 获取本行数据
+	cols, err := c.columns()
+	if err != nil {
+		return
+	}
+
+	rowErrs = c.buildOneElem(cols, c.rowNow, fields, elemPtr)
+	c.onProgress(c.rowNow)
+	return
+}
+
+// fieldDecoder 字段的解码信息
+//
+// 每次解码开始时根据目标类型的 Schema 与已注册的解析器编译一次, 避免逐行查找表头与解析器
+type fieldDecoder struct {
+	tag.Field
+	col        int                 // 该字段的表头在 excel 中的位置, -1 表示不存在
+	parser     internalFieldParser // 字段解析器
+	parserErr  error               // 获取字段解析器失败的原因, 解析到该字段时才会报告
+	empty      string              // 空单元格的处理策略, 即 tag 中的 empty 选项
+	validators []fieldValidator    // 字段解析成功后执行的校验器
+}
+
+// 空单元格的处理策略, 通过 tag 的 empty 选项指定, 如 `excel:"数量,empty=zero"`.
+// 未指定时, 带有 default 选项的字段使用 emptyDefault, 带有 omitempty 选项的字段使用 emptyZero,
+// 否则空字符串与其他值一样交给字段解析器, 指针字段保持 nil
+const (
+	emptyZero    = "zero"    // 字段保持零值, 不调用字段解析器
+	emptyDefault = "default" // 使用 default 选项的值代替空字符串进行解析, excel 中不存在该列时同样使用
+	emptyError   = "error"   // 报告 ErrEmptyCell
+)
+
+// getFields 获取目标类型的字段解码信息, 不存在时编译并缓存
+func (c *Cursor) getFields(elemType reflect.Type) (fields []fieldDecoder, err error) {
+	fields, ok := c.fieldsCache[elemType]
+	if ok {
+		return
+	}
+
+	fields, err = c.compileFields(elemType)
+	if err != nil {
+		return
+	}
+	c.fieldsCache[elemType] = fields
+	return
+}
+
+// compileFields 编译目标类型所有字段的解码信息, 顺序与结构体定义一致
+//
+// 同一字段的多个别名同时出现在表头中时, 返回 ErrHeaderAliasConflict;
+// 缺少带有 required 选项的字段对应的表头时, 返回 MissingHeadersError;
+// 严格模式下存在没有对应字段的表头时, 返回 UnknownHeadersError
+func (c *Cursor) compileFields(elemType reflect.Type) (fields []fieldDecoder, err error) {
+	schema := getSchema(elemType)
+
+	fields = make([]fieldDecoder, 0, len(schema.Fields))
+	mapped := make(map[int]bool, len(schema.Fields))
+	var missing []string
+	for _, field := range schema.Fields {
+		err = checkTagOptions(field)
+		if err != nil {
+			return
+		}
+		fd := fieldDecoder{
+			Field: field,
+			col:   -1,
+		}
+		fd.col, err = c.findCol(field.Aliases)
+		if err != nil {
+			return
+		}
+		fd.empty, err = getEmptyPolicy(field)
+		if err != nil {
+			return
+		}
+		fd.validators, err = compileValidators(field)
+		if err != nil {
+			return
+		}
+		if fd.col < 0 && field.Options.Has("required") {
+			missing = append(missing, field.Name)
+		}
+		mapped[fd.col] = true
+		fd.parser, fd.parserErr = c.getFieldParser(field.Name, field.Field.Type, field.Options)
+		fields = append(fields, fd)
+	}
+
+	if len(missing) > 0 {
+		err = errors.WithStack(&MissingHeadersError{Sheet: c.sheetName, Headers: missing})
+		return
+	}
+	if c.strict {
+		err = c.checkUnknownHeaders(mapped)
+	}
+
+	return
+}
+
+// checkUnknownHeaders 检查 excel 中是否存在不在 mapped 中的表头
+func (c *Cursor) checkUnknownHeaders(mapped map[int]bool) (err error) {
+	var cols []int
+	col2Header := make(map[int]string, len(c.headerIndex))
+	for header, col := range c.headerIndex {
+		if mapped[col] {
+			continue
+		}
+		cols = append(cols, col)
+		col2Header[col] = header
+	}
+	if len(cols) == 0 {
+		return
+	}
+
+	sort.Ints(cols)
+	unknown := make([]string, 0, len(cols))
+	for _, col := range cols {
+		unknown = append(unknown, col2Header[col])
+	}
+	err = errors.WithStack(&UnknownHeadersError{Sheet: c.sheetName, Headers: unknown})
+	return
+}
+
+// getEmptyPolicy 获取并检查字段的空单元格处理策略
+func getEmptyPolicy(field tag.Field) (empty string, err error) {
+	empty, ok := field.Options.Get("empty")
+	if !ok {
+		switch {
+		case field.Options.Has("default"):
+			empty = emptyDefault
+		case field.Options.Has("omitempty"):
+			empty = emptyZero
+		}
+	}
+
+	switch empty {
+	case "", emptyZero, emptyError:
+	case emptyDefault:
+		if !field.Options.Has("default") {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "header: %s, empty=default requires default option", field.Name)
+			err = errors.WithStack(err)
+		}
+	default:
+		err = errors.WithMessagef(ErrTagOptionInvalid, "header: %s, empty=%s", field.Name, empty)
+		err = errors.WithStack(err)
+	}
+	return
+}
+
+// findCol 获取别名列表对应的表头在 excel 中的位置, 不存在时为 -1
+//
+// 多个别名规范化后对应同一列时不视为冲突
+func (c *Cursor) findCol(aliases []string) (col int, err error) {
+	col = -1
+	found := ""
+	for _, alias := range aliases {
+		if c.headerNormalizer != nil {
+			alias = c.headerNormalizer(alias)
+		}
+		i, ok := c.headerIndex[alias]
+		if !ok || i == col {
+			continue
+		}
+		if found != "" {
+			err = errors.WithMessagef(ErrHeaderAliasConflict, "sheet: %s, headers: %s, %s", c.sheetName, found, alias)
+			err = errors.WithStack(err)
+			return
+		}
+		col = i
+		found = alias
+	}
+	return
+}
+
+// buildOneElem 将第 rowNow 个数据行的单元格解码到 elemPtr 指向的元素
+//
+// 只读取 Cursor 的配置, 不修改其状态, 可以被多个 goroutine 并发调用
 func (c *Cursor) buildOneElem(
 	cols []string,
-	tags []string, // 需要 tags slice 来确保字段解析的有序性
-	tagIndex map[string]int,
-	headerIndex map[string]int,
-	dstType reflect.Type,
+	rowNow int,
+	fields []fieldDecoder,
 	elemPtr reflect.Value,
 ) (
-	err error,
+	errs DecodeErrors,
 ) {
 	elem := elemPtr.Elem()
 
-	for _, tag := range tags {
+	for _, fd := range fields {
+		tag := fd.Name
+
 		// 获取该 tag 对应的 header 在 excel 中对应的 string 值
-		col, ok := headerIndex[tag] // 该表头在 excel 中的位置
-		if !ok {
+		col := fd.col // 该表头在 excel 中的位置
+		if col < 0 && fd.empty != emptyDefault {
 			// 该字段在 excel 中不存在
-			c.onFieldHandled(tag, "", nil, nil, -1, c.rowNow)
+			c.onFieldHandled(tag, "", nil, nil, -1, rowNow)
 			continue
 		}
-		fieldValueStr := cols[col]
+		// excelize 会去除行末尾的空单元格, 不存在的单元格视为空单元格; 不存在的列视为空单元格, 使用默认值
+		fieldValueStr := ""
+		if col >= 0 && col < len(cols) {
+			fieldValueStr = cols[col]
+		}
 
 		// 根据字段坐标获取对应的字段
-		fieldIndex := tagIndex[tag] // headers 与 tagIndex 同源, 此处一定命中
-		field := elem.Field(fieldIndex)
+		field := structure.FieldByIndexAlloc(elem, fd.Index)
 		fieldType := field.Type()
 
+		// 按照字段的策略处理空单元格
+		if fieldValueStr == "" {
+			switch {
+			case fd.empty == emptyZero,
+				fd.empty == "" && fieldType.Kind() == reflect.Ptr: // 空单元格对应的指针字段保持 nil
+				c.onFieldHandled(tag, fieldValueStr, nil, nil, col, rowNow)
+				continue
+			case fd.empty == emptyError:
+				err := errors.WithStack(ErrEmptyCell)
+				c.onFieldHandled(tag, fieldValueStr, nil, err, col, rowNow)
+				errs = append(errs, c.newDecodeError(tag, fieldValueStr, col, rowNow, err))
+				if c.errorPolicy == ErrorPolicyFailFast {
+					return
+				}
+				continue
+			case fd.empty == emptyDefault:
+				fieldValueStr, _ = fd.Options.Get("default")
+			}
+		}
+
 		// 获取字段解析器
-		var parser internalFieldParser
-		parser, err = c.getFieldParser(tag, fieldType)
-		if err != nil {
-			c.onFieldHandled(tag, fieldValueStr, nil, err, col, c.rowNow)
-			break
+		if fd.parserErr != nil {
+			c.onFieldHandled(tag, fieldValueStr, nil, fd.parserErr, col, rowNow)
+			errs = append(errs, c.newDecodeError(tag, fieldValueStr, col, rowNow, fd.parserErr))
+			if c.errorPolicy == ErrorPolicyFailFast {
+				break
+			}
+			continue
 		}
 
 		// 完成字段解析
-		var fieldValue reflect.Value
-		fieldValue, err = parser(fieldValueStr, col, c.rowNow)
+		fieldValue, err := fd.parser(fieldValueStr, col, rowNow)
 		if err != nil {
-			c.onFieldHandled(tag, fieldValueStr, nil, err, col, c.rowNow)
-			break
+			c.onFieldHandled(tag, fieldValueStr, nil, err, col, rowNow)
+			errs = append(errs, c.newDecodeError(tag, fieldValueStr, col, rowNow, err))
+			if c.errorPolicy == ErrorPolicyFailFast {
+				break
+			}
+			continue
+		}
+		// 解析器返回 nil 时字段保持零值, 指针字段保持 nil
+		if !fieldValue.IsValid() {
+			fieldValue = reflect.Zero(fieldType)
+		}
+		// tag 解析器可能返回指针字段所指向类型的值
+		if fieldType.Kind() == reflect.Ptr && fieldValue.Type() != fieldType {
+			ptr := reflect.New(fieldType.Elem())
+			ptr.Elem().Set(fieldValue)
+			fieldValue = ptr
+		}
+
+		// 校验解析结果, 校验失败的字段保持零值
+		err = validateField(fd.validators, fieldValue)
+		if err != nil {
+			c.onFieldHandled(tag, fieldValueStr, fieldValue.Interface(), err, col, rowNow)
+			errs = append(errs, c.newDecodeError(tag, fieldValueStr, col, rowNow, err))
+			if c.errorPolicy == ErrorPolicyFailFast {
+				break
+			}
+			continue
 		}
 		field.Set(fieldValue)
-		c.onFieldHandled(tag, fieldValueStr, fieldValue.Interface(), nil, col, c.rowNow)
+		c.onFieldHandled(tag, fieldValueStr, fieldValue.Interface(), nil, col, rowNow)
+	}
+
+	// 所有字段都解析成功后, 进行跨字段的校验
+	if len(errs) == 0 {
+		errs = c.validateElem(elemPtr, rowNow)
 	}
 
 	return
 }
 
+// newDecodeError 构造第 rowNow 个数据行指定列的解码错误
+func (c *Cursor) newDecodeError(header string, valueStr string, col int, rowNow int, err error) (de *DecodeError) {
+	colName, _ := excelize.ColumnNumberToName(col + 1) // col 从 0 开始, excel 列号从 1 开始
+	de = &DecodeError{
+		Sheet:  c.sheetName,
+		Row:    rowNow + c.rowOffset,
+		Col:    colName,
+		Header: header,
+		Value:  valueStr,
+		Err:    err,
+	}
+	return
+}
+
 func (c *Cursor) initTypeParsers() {
 	// int kind
 	c.RegisterTypeParser(int(0), str2int)
@@ -266,6 +954,17 @@ func (c *Cursor) initTypeParsers() {
 
 	// bool
 	c.RegisterTypeParser(true, str2bool)
+
+	// time
+	c.RegisterTypeParser(time.Time{}, c.timeParser(""))
+	c.RegisterTypeParser(time.Duration(0), str2duration)
+}
+
+func (c *Cursor) onProgress(rowNow int) {
+	if c.progressHandler == nil {
+		return
+	}
+	c.progressHandler(rowNow, c.totalRows)
 }
 
 func (c *Cursor) onFieldHandled(header string, valueStr string, value interface{}, err error, col int, row int) {
@@ -274,3 +973,34 @@ func (c *Cursor) onFieldHandled(header string, valueStr string, value interface{
 	}
 	c.afterFieldHandler(header, valueStr, value, err, col, row)
 }
+
+// countTotalRows 通过额外的一次遍历获取数据总行数, 即表头之后 sheet 的所有行, 无法获取时返回 -1
+func (c *Cursor) countTotalRows() (total int) {
+	total = -1
+	if c.ef == nil {
+		return
+	}
+	rows, err := c.ef.Rows(c.sheetName)
+	if err != nil {
+		return
+	}
+
+	total = 0
+	for rows.Next() {
+		total++
+	}
+	total -= c.rowOffset
+	if total < 0 {
+		total = 0
+	}
+	return
+}
+
+// contextErr 如果 ctx 已取消, 返回带有堆栈的 ctx.Err()
+func contextErr(ctx context.Context) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		err = errors.WithStack(err)
+	}
+	return
+}
@@ -1,8 +1,10 @@
 package excel
 
 import (
+	"context"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -161,7 +163,7 @@ func Test_onFieldHandled(t *testing.T) {
 	}
 	for i := 0; i < 8; i++ {
 		err := errs[i]
-		if assert.Error(t, err) {
+		if !assert.NoError(t, err) {
 			return
 		}
 	}
@@ -173,3 +175,513 @@ func Test_onFieldHandled(t *testing.T) {
 		},
 	)
 }
+
+func Test_DecodePointer(t *testing.T) {
+	type Supplier struct {
+		Name  string    `excel:"name"`
+		Stock *int      `excel:"stock"`
+		Note  *string   `excel:"note"`
+		Rate  **float64 `excel:"rate"`
+	}
+
+	f := openTestFile(t, [][]interface{}{
+		{"name", "stock", "note", "rate"},
+		{"a", "0", "", "1.5"},
+		{"b", "", "hello", ""},
+	})
+
+	var suppliers []Supplier
+	_, err := f.DecodeAll(&suppliers)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, suppliers, 2) {
+		return
+	}
+
+	if assert.NotNil(t, suppliers[0].Stock) {
+		assert.Equal(t, 0, *suppliers[0].Stock)
+	}
+	assert.Nil(t, suppliers[0].Note)
+	if assert.NotNil(t, suppliers[0].Rate) && assert.NotNil(t, *suppliers[0].Rate) {
+		assert.Equal(t, 1.5, **suppliers[0].Rate)
+	}
+
+	assert.Nil(t, suppliers[1].Stock)
+	if assert.NotNil(t, suppliers[1].Note) {
+		assert.Equal(t, "hello", *suppliers[1].Note)
+	}
+	assert.Nil(t, suppliers[1].Rate)
+}
+
+func Test_DecodeParserReturnsNil(t *testing.T) {
+	type Supplier4NilParser struct {
+		Stock *int     `excel:"stock"`
+		Rate  *float64 `excel:"rate"`
+		Score float64  `excel:"score"`
+	}
+	f := openTestFile(t, [][]interface{}{
+		{"stock", "rate", "score"},
+		{"N/A", "-", "-"},
+		{"3", "1.5", "2"},
+	})
+
+	// 解析器返回 nil 表示没有值, 指针字段保持 nil, 其他字段保持零值
+	f.RegisterTagParser("stock", func(valueStr string, col int, row int) (value interface{}, err error) {
+		if valueStr == "N/A" {
+			return nil, nil
+		}
+		return str2int(valueStr, col, row)
+	})
+	f.RegisterTypeParser(float64(0), func(valueStr string, col int, row int) (value interface{}, err error) {
+		if valueStr == "-" {
+			return nil, nil
+		}
+		return str2float64(valueStr, col, row)
+	})
+
+	var suppliers []Supplier4NilParser
+	if !assert.NoError(t, f.Decode(&suppliers)) || !assert.Len(t, suppliers, 2) {
+		return
+	}
+	assert.Equal(t, Supplier4NilParser{}, suppliers[0])
+	stock, rate := 3, 1.5
+	assert.Equal(t, Supplier4NilParser{Stock: &stock, Rate: &rate, Score: 2}, suppliers[1])
+}
+
+func decodeLocalCustomerNames(t *testing.T, f *File) (names []string) {
+	type Customer struct {
+		Name string `excel:"name"`
+	}
+	var customers []Customer
+	if !assert.NoError(t, f.Decode(&customers)) {
+		return
+	}
+	for _, c := range customers {
+		names = append(names, c.Name)
+	}
+	return
+}
+
+func decodeLocalCustomerAges(t *testing.T, f *File) (ages []int) {
+	type Customer struct {
+		Age int `excel:"age"`
+	}
+	var customers []Customer
+	if !assert.NoError(t, f.Decode(&customers)) {
+		return
+	}
+	for _, c := range customers {
+		ages = append(ages, c.Age)
+	}
+	return
+}
+
+func Test_DecodeLocalTypesWithSameName(t *testing.T) {
+	rows := [][]interface{}{
+		{"name", "age"},
+		{"a", "1"},
+		{"b", "2"},
+	}
+
+	assert.Equal(t, []string{"a", "b"}, decodeLocalCustomerNames(t, openTestFile(t, rows)))
+	assert.Equal(t, []int{1, 2}, decodeLocalCustomerAges(t, openTestFile(t, rows)))
+}
+
+func Test_Scan(t *testing.T) {
+	type Customer4Scan struct {
+		Name string `excel:"name"`
+		Age  int    `excel:"age"`
+	}
+	f := openTestFile(t, [][]interface{}{
+		{"name", "age"},
+		{"a", "1"},
+		{"b", "x"},
+	})
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var customer Customer4Scan
+	if !assert.True(t, c.Next()) {
+		return
+	}
+	if !assert.NoError(t, c.Scan(&customer)) {
+		return
+	}
+	assert.Equal(t, Customer4Scan{Name: "a", Age: 1}, customer)
+	assert.Equal(t, 2, c.Row())
+
+	if !assert.True(t, c.Next()) {
+		return
+	}
+	err = c.Scan(&customer)
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) {
+		assert.Equal(t, 3, decodeErrs[0].Row)
+	}
+
+	assert.False(t, c.Next())
+	assert.Error(t, c.Scan(customer))
+}
+
+func Test_Each(t *testing.T) {
+	type Customer4Each struct {
+		Name string `excel:"name"`
+		Age  *int   `excel:"age"`
+	}
+	f := openTestFile(t, [][]interface{}{
+		{"name", "age"},
+		{"a", "1"},
+		{"b", "x"},
+		{"c", ""},
+		{"d", "4"},
+	})
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var rows []int
+	var names []string
+	var customer Customer4Each
+	err := f.Each(&customer, func(row int, elemPtr interface{}) error {
+		c := elemPtr.(*Customer4Each)
+		rows = append(rows, row)
+		names = append(names, c.Name)
+		if c.Name == "c" {
+			// 每行解码前元素会被重置
+			assert.Nil(t, c.Age)
+		}
+		return nil
+	})
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) {
+		assert.Len(t, decodeErrs, 1)
+	}
+	assert.Equal(t, []int{2, 4, 5}, rows)
+	assert.Equal(t, []string{"a", "c", "d"}, names)
+
+	// 回调返回错误时停止迭代
+	stop := errors.New("stop")
+	count := 0
+	err = f.Each(&customer, func(row int, elemPtr interface{}) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func Test_DecodeBatches(t *testing.T) {
+	type Customer4Batches struct {
+		Name string `excel:"name"`
+		Age  int    `excel:"age"`
+	}
+	rows := [][]interface{}{{"name", "age"}}
+	for i := 0; i < 7; i++ {
+		rows = append(rows, []interface{}{string(rune('a' + i)), i})
+	}
+	rows[3][1] = "x" // 第 4 行解析失败
+	f := openTestFile(t, rows)
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var batches [][]Customer4Batches
+	var ranges [][2]int
+	var customers []Customer4Batches
+	err := f.DecodeBatches(&customers, 3, func(batch interface{}, firstRow int, lastRow int) error {
+		batches = append(batches, batch.([]Customer4Batches))
+		ranges = append(ranges, [2]int{firstRow, lastRow})
+		return nil
+	})
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 1) {
+		assert.Equal(t, 4, decodeErrs[0].Row)
+	}
+	assert.Equal(t, [][]Customer4Batches{
+		{{Name: "a", Age: 0}, {Name: "b", Age: 1}, {Name: "d", Age: 3}},
+		{{Name: "e", Age: 4}, {Name: "f", Age: 5}, {Name: "g", Age: 6}},
+	}, batches)
+	assert.Equal(t, [][2]int{{2, 5}, {6, 8}}, ranges) // 第一批包含被丢弃的第 4 行
+
+	// 回调返回错误时停止迭代
+	stop := errors.New("stop")
+	count := 0
+	err = f.DecodeBatches(&customers, 2, func(batch interface{}, firstRow int, lastRow int) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+
+	// 批次大小非法
+	err = f.DecodeBatches(&customers, 0, nil)
+	assert.True(t, errors.Is(err, ErrBatchSizeInvalid))
+}
+
+func Test_DecodeContextAndProgress(t *testing.T) {
+	type Customer4Context struct {
+		Name string `excel:"name"`
+	}
+	f := openTestFile(t, [][]interface{}{{"name"}, {"a"}, {"b"}, {"c"}})
+
+	var processed, totals []int
+	f.OnProgress(func(p int, total int) {
+		processed = append(processed, p)
+		totals = append(totals, total)
+	})
+
+	var customers []Customer4Context
+	_, err := f.DecodeAllContext(context.Background(), &customers)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, customers, 3)
+	assert.Equal(t, []int{1, 2, 3}, processed)
+	assert.Equal(t, []int{3, 3, 3}, totals)
+
+	// 在 Cursor 已读取部分数据后设置回调, 总行数依然可知
+	f.OnProgress(nil)
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = c.DecodeMany(&customers, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	totals = nil
+	c.OnProgress(func(p int, total int) {
+		totals = append(totals, total)
+	})
+	_, err = c.DecodeMany(&customers, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{3, 3}, totals)
+	}
+
+	// 解码到第 2 行时取消
+	ctx, cancel := context.WithCancel(context.Background())
+	f.OnProgress(func(p int, total int) {
+		if p == 2 {
+			cancel()
+		}
+	})
+	err = f.DecodeContext(ctx, &customers)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	count := 0
+	err = f.EachContext(ctx, &Customer4Context{}, func(row int, elemPtr interface{}) error {
+		count++
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, count)
+}
+
+func Test_DecodeHeaderAliases(t *testing.T) {
+	type Customer4Aliases struct {
+		Name   string `excel:"name|姓名"`
+		Mobile string `excel:"手机号|手机号码|Mobile"`
+	}
+
+	f := openTestFile(t, [][]interface{}{{"姓名", "Mobile"}, {"a", "123"}})
+	var customers []Customer4Aliases
+	err := f.Decode(&customers)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Customer4Aliases{{Name: "a", Mobile: "123"}}, customers)
+	}
+
+	// 写入时使用第一个别名
+	w := NewFile()
+	if !assert.NoError(t, w.Write(customers)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	rf, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := rf.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"name", "手机号"}, rows[0])
+	}
+
+	// 同一字段的多个别名同时出现
+	f = openTestFile(t, [][]interface{}{{"姓名", "手机号", "Mobile"}, {"a", "123", "456"}})
+	err = f.Decode(&customers)
+	assert.True(t, errors.Is(err, ErrHeaderAliasConflict))
+}
+
+func Test_BlankRowsAndEndOfTable(t *testing.T) {
+	type Customer4Blank struct {
+		Name string `excel:"name"`
+		Age  int    `excel:"age"`
+	}
+	rows := [][]interface{}{
+		{"name", "age"},
+		{"a", 1},
+		{"", " "},
+		{"b", 2},
+		{"合计", 3},
+		{},
+		{"", ""},
+	}
+
+	// 默认空白行解码为零值元素
+	f := openTestFile(t, rows)
+	var customers []Customer4Blank
+	err := f.Decode(&customers)
+	assert.Error(t, err) // 空白单元格无法解析为 int
+
+	// 跳过空白行
+	f.SetBlankRowPolicy(BlankRowSkip)
+	customers = nil
+	if assert.NoError(t, f.Decode(&customers)) {
+		assert.Equal(t, []Customer4Blank{{"a", 1}, {"b", 2}, {"合计", 3}}, customers)
+	}
+
+	// 遇到空白行停止
+	f.SetBlankRowPolicy(BlankRowStop)
+	_, err = f.DecodeAll(&customers)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Customer4Blank{{"a", 1}}, customers)
+	}
+
+	// 遇到合计行停止, 合计行之后的空白行不影响 DecodeAll 的数据总量检查
+	f.SetBlankRowPolicy(BlankRowSkip)
+	f.SetEndOfTable(func(cols []string) bool {
+		return len(cols) > 0 && cols[0] == "合计"
+	})
+	f.SetMaxDecodeAllCount(2)
+	_, err = f.DecodeAll(&customers)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Customer4Blank{{"a", 1}, {"b", 2}}, customers)
+	}
+
+	// 逐行迭代时行号跳过空白行
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var rowNums []int
+	for c.Next() {
+		rowNums = append(rowNums, c.Row())
+	}
+	assert.Equal(t, []int{2, 4}, rowNums)
+	assert.False(t, c.Next())
+}
+
+func Test_DecodeRaggedRows(t *testing.T) {
+	type Customer4Ragged struct {
+		Name   string  `excel:"name"`
+		Age    *int    `excel:"age"`
+		Remark string  `excel:"remark"`
+		Rank   float64 `excel:"rank,empty=zero"`
+	}
+	// excelize 会去除行末尾的空单元格, 各行的宽度不同
+	f := openTestFile(t, [][]interface{}{
+		{"name", "age", "remark", "rank"},
+		{"a"},
+		{"b", 2},
+		{},
+		{"c", nil, "x"},
+		{"d", 4, "y", 1.5},
+	})
+	var customers []Customer4Ragged
+	if !assert.NoError(t, f.Decode(&customers)) {
+		return
+	}
+	age2, age4 := 2, 4
+	assert.Equal(t, []Customer4Ragged{
+		{Name: "a"},
+		{Name: "b", Age: &age2},
+		{},
+		{Name: "c", Remark: "x"},
+		{Name: "d", Age: &age4, Remark: "y", Rank: 1.5},
+	}, customers)
+
+	// 并行解码同样安全
+	f.SetParallelism(2)
+	customers = nil
+	assert.NoError(t, f.Decode(&customers))
+	assert.Len(t, customers, 5)
+}
+
+func Test_DecodeEmptyPolicy(t *testing.T) {
+	type Customer4Empty struct {
+		Name  string `excel:"name,empty=error"`
+		Count int    `excel:"count,empty=default,default=1"`
+		Level int    `excel:"level,empty=zero"`
+		Score int    `excel:"score"`
+	}
+	f := openTestFile(t, [][]interface{}{
+		{"name", "count", "level", "score"},
+		{"a", "", "", 1},
+		{"", 2, 3, 4},
+		{"c", nil, nil},
+	})
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var customers []Customer4Empty
+	err := f.Decode(&customers)
+	assert.Equal(t, []Customer4Empty{{Name: "a", Count: 1, Score: 1}}, customers)
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 2) {
+		// 空单元格与不存在的单元格同样处理
+		assert.Equal(t, "A", decodeErrs[0].Col)
+		assert.Equal(t, 3, decodeErrs[0].Row)
+		assert.True(t, errors.Is(decodeErrs[0], ErrEmptyCell))
+		assert.Equal(t, "D", decodeErrs[1].Col)
+		assert.Equal(t, 4, decodeErrs[1].Row)
+	}
+
+	// 非法的 empty 选项
+	type Customer4InvalidEmpty struct {
+		Name  string `excel:"name,empty=none"`
+		Count int    `excel:"count,empty=default"`
+	}
+	var invalid []Customer4InvalidEmpty
+	err = f.Decode(&invalid)
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+}
+
+func Test_DecodeDefaultAndOmitempty(t *testing.T) {
+	type Item4Default struct {
+		Name     string  `excel:"name,default=未命名"`
+		Count    int     `excel:"count,default=1"`
+		Price    float64 `excel:"price,omitempty"`
+		Discount *int    `excel:"discount,default=100"`
+		Unit     string  `excel:"unit,default=个"` // excel 中不存在该列
+		Level    int     `excel:"level,default=x,empty=zero"`
+	}
+	f := openTestFile(t, [][]interface{}{
+		{"name", "count", "price", "discount", "level"},
+		{"a", 2, 1.5, 90, 3},
+		{"", "", "", "", ""},
+		{"c"},
+	})
+
+	var items []Item4Default
+	if !assert.NoError(t, f.Decode(&items)) {
+		return
+	}
+	discount90, discount100 := 90, 100
+	assert.Equal(t, []Item4Default{
+		{Name: "a", Count: 2, Price: 1.5, Discount: &discount90, Unit: "个", Level: 3},
+		{Name: "未命名", Count: 1, Discount: &discount100, Unit: "个"},
+		{Name: "c", Count: 1, Discount: &discount100, Unit: "个"},
+	}, items)
+
+	// 默认值同样经过字段解析器
+	type Item4InvalidDefault struct {
+		Count int `excel:"count,default=x"`
+	}
+	var invalid []Item4InvalidDefault
+	err := f.Decode(&invalid)
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 1) {
+		assert.Equal(t, 3, decodeErrs[0].Row)
+		assert.Equal(t, "B", decodeErrs[0].Col)
+	}
+}
@@ -0,0 +1,309 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure/tag"
+)
+
+// excel 支持的日期范围, 以 1900 日期系统的序列号表示
+const (
+	minExcelDate = 1       // 1900-01-01
+	maxExcelDate = 2958465 // 9999-12-31
+)
+
+const (
+	maxDropListLen    = 255          // 直接写在规则中的下拉列表的最大长度, 包含引号与分隔的逗号
+	dropListSheetName = "_droplists" // 存放过长的下拉列表可选值的隐藏 sheet
+)
+
+// dropListRefFunc 将下拉列表的可选值写入单元格, 返回引用这些单元格的区域
+type dropListRefFunc func(items []string) (ref string, err error)
+
+// SetValidationRows 设置数据有效性规则覆盖的行数, 从表头的下一行开始, 不大于 0 时不生成规则, 默认为 0
+//
+// 规则根据字段的 tag 生成: 枚举与 oneof 选项生成下拉列表, 可选值过长或包含逗号时写入隐藏的 "_droplists" sheet 并引用其区域,
+// 数值字段的 min、max 选项生成数值范围,
+// 字符串字段的 min、max、len 选项生成长度限制, 时间字段只允许输入日期, required 选项生成必填的输入提示.
+// 必须在写入表头之前设置
+func (s *Stream) SetValidationRows(n int) {
+	s.validationRows = n
+}
+
+// addDataValidations 为每个字段所在的列添加数据有效性规则, 覆盖表头之后的 validationRows 行
+func (s *Stream) addDataValidations() (err error) {
+	if s.validationRows <= 0 || s.schema == nil {
+		return
+	}
+
+	for col, field := range s.schema.Fields {
+		var dv *excelize.DataValidation
+		dv, err = fieldDataValidation(field, s.enums, s.dropListRef)
+		if err != nil {
+			return
+		}
+		if dv == nil {
+			continue
+		}
+
+		dv.Sqref = cellName(col, s.rowNow) + ":" + cellName(col, s.rowNow+s.validationRows-1)
+		err = s.ef.AddDataValidation(s.sheetName, dv)
+		if err != nil {
+			err = errors.WithMessagef(err, "header: %s", field.Name)
+			err = errors.WithStack(err)
+			return
+		}
+	}
+
+	return
+}
+
+// dropListRef 将过长的下拉列表的可选值写入隐藏的 sheet, 每个列表占一列, 相同的列表只写入一次
+func (s *Stream) dropListRef(items []string) (ref string, err error) {
+	key := strings.Join(items, "\n")
+	ref, ok := s.dropListRefs[key]
+	if ok {
+		return
+	}
+
+	col := 0
+	if s.ef.GetSheetIndex(dropListSheetName) == -1 {
+		s.ef.NewSheet(dropListSheetName)
+		err = s.ef.SetSheetVisible(dropListSheetName, false)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	} else {
+		// 其他写入器已写入的列表在前面的列
+		var cols [][]string
+		cols, err = s.ef.GetCols(dropListSheetName)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+		col = len(cols)
+	}
+
+	for row, item := range items {
+		err = s.ef.SetCellStr(dropListSheetName, cellName(col, row), item)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+	colName, err := excelize.ColumnNumberToName(col + 1)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	ref = fmt.Sprintf("'%s'!$%s$1:$%s$%d", dropListSheetName, colName, colName, len(items))
+	if s.dropListRefs == nil {
+		s.dropListRefs = make(map[string]string)
+	}
+	s.dropListRefs[key] = ref
+	return
+}
+
+// fieldDataValidation 根据字段的 tag 生成数据有效性规则, 不需要规则时 dv 为 nil
+func fieldDataValidation(
+	field tag.Field,
+	enums map[string]*enum,
+	dropListRef dropListRefFunc,
+) (
+	dv *excelize.DataValidation,
+	err error,
+) {
+	opts := field.Options
+	required := opts.Has("required")
+	dv = excelize.NewDataValidation(!required)
+
+	hasRule, msg, err := setDataValidationRule(dv, field, enums, dropListRef)
+	if err != nil {
+		err = errors.WithMessagef(err, "header: %s", field.Name)
+		err = errors.WithStack(err)
+		return
+	}
+	if hasRule {
+		dv.SetError(excelize.DataValidationErrorStyleStop, field.Name, msg)
+	}
+	if required {
+		if msg == "" {
+			msg = "必填"
+		} else {
+			msg = "必填, " + msg
+		}
+		dv.SetInput(field.Name, msg)
+	}
+	if !hasRule && !required {
+		dv = nil
+	}
+	return
+}
+
+// setDataValidationRule 为 dv 设置字段的校验规则, msg 为规则的描述
+//
+// 下拉列表无法直接写在规则中时通过 dropListRef 引用单元格区域, dropListRef 为 nil 时不生成下拉列表
+func setDataValidationRule(
+	dv *excelize.DataValidation,
+	field tag.Field,
+	enums map[string]*enum,
+	dropListRef dropListRefFunc,
+) (
+	hasRule bool,
+	msg string,
+	err error,
+) {
+	opts := field.Options
+
+	// 下拉列表
+	e, found, err := getEnum(enums, opts)
+	if err != nil {
+		return
+	}
+	var items []string
+	if found {
+		items = e.labels
+	} else if s, ok := opts.Get("oneof"); ok {
+		items = strings.Fields(s)
+	}
+	if len(items) > 0 {
+		msg = "请从下拉列表中选择"
+		if !inlineDropList(items) {
+			if dropListRef == nil {
+				return
+			}
+			var ref string
+			ref, err = dropListRef(items)
+			if err != nil {
+				return
+			}
+			err = dv.SetSqrefDropList(ref, true)
+		} else {
+			err = dv.SetDropList(items)
+		}
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+		hasRule = true
+		return
+	}
+
+	t := field.Field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// 日期
+	if t == timeType {
+		err = dv.SetRange(minExcelDate, maxExcelDate, excelize.DataValidationTypeDate, excelize.DataValidationOperatorBetween)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+		hasRule = true
+		msg = "请输入日期"
+		return
+	}
+
+	// 数值范围与文本长度
+	var rangeType excelize.DataValidationType
+	var unit string
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		rangeType, unit = excelize.DataValidationTypeWhole, "整数"
+	case reflect.Float32, reflect.Float64:
+		rangeType, unit = excelize.DataValidationTypeDecimal, "数值"
+	case reflect.String:
+		rangeType, unit = excelize.DataValidationTypeTextLeng, "个字符"
+	default:
+		return
+	}
+	hasRule, msg, err = setDataValidationRange(dv, opts, rangeType, unit)
+	return
+}
+
+// inlineDropList 下拉列表能否直接写在规则中, 可选值以逗号分隔且总长度不能超过 maxDropListLen
+func inlineDropList(items []string) bool {
+	length := 2 + len(items) - 1 // 两侧的引号与分隔的逗号
+	for _, item := range items {
+		if strings.Contains(item, ",") {
+			return false
+		}
+		length += len(item)
+	}
+	return length <= maxDropListLen
+}
+
+// setDataValidationRange 根据 min、max、len 选项为 dv 设置数值范围或文本长度
+func setDataValidationRange(
+	dv *excelize.DataValidation,
+	opts tag.Options,
+	rangeType excelize.DataValidationType,
+	unit string,
+) (
+	hasRule bool,
+	msg string,
+	err error,
+) {
+	var bounds [2]float64
+	var has [2]bool
+	for i, key := range []string{"min", "max"} {
+		s, ok := opts.Get(key)
+		if !ok {
+			continue
+		}
+		bounds[i], err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "%s=%s", key, s)
+			return
+		}
+		has[i] = true
+	}
+	if s, ok := opts.Get("len"); ok && rangeType == excelize.DataValidationTypeTextLeng {
+		bounds[0], err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "len=%s", s)
+			return
+		}
+		bounds[1] = bounds[0]
+		has = [2]bool{true, true}
+	}
+
+	isText := rangeType == excelize.DataValidationTypeTextLeng
+	switch {
+	case has[0] && has[1]:
+		err = dv.SetRange(bounds[0], bounds[1], rangeType, excelize.DataValidationOperatorBetween)
+		msg = fmt.Sprintf("请输入 %v 到 %v 之间的%s", bounds[0], bounds[1], unit)
+		if isText {
+			msg = fmt.Sprintf("请输入 %v 到 %v %s", bounds[0], bounds[1], unit)
+		}
+	case has[0]:
+		err = dv.SetRange(bounds[0], 0, rangeType, excelize.DataValidationOperatorGreaterThanOrEqual)
+		msg = fmt.Sprintf("请输入不小于 %v 的%s", bounds[0], unit)
+		if isText {
+			msg = fmt.Sprintf("请输入至少 %v %s", bounds[0], unit)
+		}
+	case has[1]:
+		err = dv.SetRange(bounds[1], 0, rangeType, excelize.DataValidationOperatorLessThanOrEqual)
+		msg = fmt.Sprintf("请输入不大于 %v 的%s", bounds[1], unit)
+		if isText {
+			msg = fmt.Sprintf("请输入至多 %v %s", bounds[1], unit)
+		}
+	default:
+		return
+	}
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	hasRule = true
+	return
+}
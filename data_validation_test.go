@@ -0,0 +1,192 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// xlsxDataValidations 用于测试中读取 sheet 中的数据有效性规则
+type xlsxDataValidations struct {
+	DataValidations []struct {
+		Type         string `xml:"type,attr"`
+		Operator     string `xml:"operator,attr"`
+		AllowBlank   bool   `xml:"allowBlank,attr"`
+		ShowInput    bool   `xml:"showInputMessage,attr"`
+		Sqref        string `xml:"sqref,attr"`
+		Prompt       string `xml:"prompt,attr"`
+		Formula1     string `xml:"formula1"`
+		Formula2     string `xml:"formula2"`
+		ErrorMessage string `xml:"error,attr"`
+	} `xml:"dataValidations>dataValidation"`
+}
+
+// readDataValidations 读取 excel 文件中第一个 sheet 的数据有效性规则
+func readDataValidations(t *testing.T, buf *bytes.Buffer) (dvs xlsxDataValidations) {
+	readSheetXML(t, buf, &dvs)
+	return
+}
+
+// readSheetXML 将 excel 文件中第一个 sheet 的 xml 解析到 v
+func readSheetXML(t *testing.T, buf *bytes.Buffer, v interface{}) {
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, zf := range r.File {
+		if zf.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := zf.Open()
+		if !assert.NoError(t, err) {
+			return
+		}
+		data, err := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, xml.Unmarshal(data, v))
+	}
+}
+
+func Test_DataValidation(t *testing.T) {
+	type Customer4DataValidation struct {
+		Name     string    `excel:"name,required,min=2,max=10"`
+		Gender   int       `excel:"gender,enum=gender"`
+		Level    string    `excel:"level,oneof=A B C"`
+		Age      int       `excel:"age,min=0,max=150"`
+		Score    *float64  `excel:"score,min=0.5"`
+		Birthday time.Time `excel:"birthday"`
+		Remark   string    `excel:"remark"`
+	}
+	customers := []Customer4DataValidation{{Name: "ab", Gender: 1, Level: "A", Birthday: time.Now()}}
+
+	f := NewFile()
+	f.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+	f.SetValidationRows(100)
+	if !assert.NoError(t, f.Write(customers)) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dvs := readDataValidations(t, buf).DataValidations
+	if !assert.Len(t, dvs, 6) {
+		return
+	}
+
+	assert.Equal(t, "textLength", dvs[0].Type)
+	assert.Equal(t, "between", dvs[0].Operator)
+	assert.Equal(t, "A2:A101", dvs[0].Sqref)
+	assert.Equal(t, "2.000000", dvs[0].Formula1)
+	assert.Equal(t, "10.000000", dvs[0].Formula2)
+	assert.False(t, dvs[0].AllowBlank)
+	assert.True(t, dvs[0].ShowInput)
+	assert.Equal(t, "必填, 请输入 2 到 10 个字符", dvs[0].Prompt)
+
+	assert.Equal(t, "list", dvs[1].Type)
+	assert.Equal(t, "B2:B101", dvs[1].Sqref)
+	assert.Equal(t, `"女,男"`, dvs[1].Formula1)
+	assert.True(t, dvs[1].AllowBlank)
+
+	assert.Equal(t, "list", dvs[2].Type)
+	assert.Equal(t, `"A,B,C"`, dvs[2].Formula1)
+
+	assert.Equal(t, "whole", dvs[3].Type)
+	assert.Equal(t, "between", dvs[3].Operator)
+	assert.Equal(t, "请输入 0 到 150 之间的整数", dvs[3].ErrorMessage)
+
+	assert.Equal(t, "decimal", dvs[4].Type)
+	assert.Equal(t, "greaterThanOrEqual", dvs[4].Operator)
+	assert.Equal(t, "0.500000", dvs[4].Formula1)
+
+	assert.Equal(t, "date", dvs[5].Type)
+	assert.Equal(t, "F2:F101", dvs[5].Sqref)
+
+	// 写入的数据不受影响
+	r, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+	var decoded []Customer4DataValidation
+	if assert.NoError(t, r.Decode(&decoded)) && assert.Len(t, decoded, 1) {
+		assert.Equal(t, "ab", decoded[0].Name)
+	}
+
+	// 默认不生成规则
+	f = NewFile()
+	f.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+	if !assert.NoError(t, f.Write(customers)) {
+		return
+	}
+	buf, err = f.ExportBuffer()
+	if assert.NoError(t, err) {
+		assert.Empty(t, readDataValidations(t, buf).DataValidations)
+	}
+}
+
+func Test_DataValidationLargeEnum(t *testing.T) {
+	type Employee4DataValidation struct {
+		Name     string `excel:"name"`
+		Dept     int    `excel:"dept,enum=dept"`
+		PrevDept *int   `excel:"prev_dept,enum=dept"`
+		Level    string `excel:"level,oneof=A B C"`
+	}
+	depts := make(map[string]interface{}, 60)
+	labels := make([]string, 0, 60)
+	for i := 1; i <= 60; i++ {
+		label := fmt.Sprintf("部门%02d", i)
+		depts[label] = i
+		labels = append(labels, label)
+	}
+
+	f := NewFile()
+	f.RegisterEnum("dept", depts)
+	f.SetValidationRows(10)
+	if !assert.NoError(t, f.Write([]Employee4DataValidation{{Name: "a", Dept: 60, Level: "A"}})) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 超出长度限制的下拉列表引用隐藏 sheet 中的区域, 相同的列表只写入一次
+	dvs := readDataValidations(t, buf).DataValidations
+	if !assert.Len(t, dvs, 3) {
+		return
+	}
+	assert.Equal(t, "list", dvs[0].Type)
+	assert.Equal(t, "B2:B11", dvs[0].Sqref)
+	assert.Equal(t, "'_droplists'!$A$1:$A$60", dvs[0].Formula1)
+	assert.Equal(t, "C2:C11", dvs[1].Sqref)
+	assert.Equal(t, "'_droplists'!$A$1:$A$60", dvs[1].Formula1)
+	assert.Equal(t, `"A,B,C"`, dvs[2].Formula1)
+
+	r, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	cols, err := r.Export().GetCols(dropListSheetName)
+	if assert.NoError(t, err) && assert.Len(t, cols, 1) {
+		assert.Equal(t, labels, cols[0])
+	}
+	assert.False(t, r.Export().GetSheetVisible(dropListSheetName))
+
+	// 写入的数据不受影响
+	r.RegisterEnum("dept", depts)
+	var decoded []Employee4DataValidation
+	if assert.NoError(t, r.Decode(&decoded)) {
+		assert.Equal(t, []Employee4DataValidation{{Name: "a", Dept: 60, Level: "A"}}, decoded)
+	}
+}
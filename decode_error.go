@@ -0,0 +1,112 @@
+package excel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorPolicy 解码过程中遇到字段错误时的处理策略
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyFailFast 遇到第一个错误立即停止解码, 出错的行不会被追加到结果中
+	ErrorPolicyFailFast ErrorPolicy = iota
+	// ErrorPolicySkipRow 收集错误并跳过出错的行, 继续解码后续行
+	ErrorPolicySkipRow
+	// ErrorPolicyKeepZero 收集错误, 出错的字段保留零值, 该行仍被追加到结果中
+	ErrorPolicyKeepZero
+)
+
+// DecodeError 单元格解码错误
+type DecodeError struct {
+	Sheet  string // sheet 名
+	Row    int    // excel 中的行号, 从 1 开始
+	Col    string // excel 中的列名, 如 "A"
+	Header string // 表头
+	Value  string // 单元格的原始字符串
+	Err    error  // 导致错误的原因
+}
+
+// Error 实现 error 接口
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf(
+		"sheet: %s, cell: %s%d, header: %s, value: %s: %v",
+		e.Sheet,
+		e.Col,
+		e.Row,
+		e.Header,
+		e.Value,
+		e.Err,
+	)
+}
+
+// Unwrap 返回导致错误的原因
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors 解码过程中收集到的所有单元格错误
+type DecodeErrors []*DecodeError
+
+// Error 实现 error 接口
+func (es DecodeErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("%d decode errors: %s", len(es), strings.Join(msgs, "; "))
+}
+
+// Is 任意一个单元格错误命中 target 即返回 true
+func (es DecodeErrors) Is(target error) bool {
+	for _, e := range es {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 将第一个可以匹配 target 的单元格错误赋值给 target
+func (es DecodeErrors) As(target interface{}) bool {
+	for _, e := range es {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingHeadersError excel 中缺少带有 required 选项的字段对应的表头
+type MissingHeadersError struct {
+	Sheet   string   // sheet 名
+	Headers []string // 所有缺少的表头, 顺序与结构体定义一致
+}
+
+// Error 实现 error 接口
+func (e *MissingHeadersError) Error() string {
+	return fmt.Sprintf("sheet: %s, %v: %s", e.Sheet, ErrMissingHeaders, strings.Join(e.Headers, ", "))
+}
+
+// Unwrap 返回 ErrMissingHeaders
+func (e *MissingHeadersError) Unwrap() error {
+	return ErrMissingHeaders
+}
+
+// UnknownHeadersError 严格模式下, excel 中存在没有对应字段的表头
+type UnknownHeadersError struct {
+	Sheet   string   // sheet 名
+	Headers []string // 所有未知的表头, 顺序与 excel 中的列一致
+}
+
+// Error 实现 error 接口
+func (e *UnknownHeadersError) Error() string {
+	return fmt.Sprintf("sheet: %s, %v: %s", e.Sheet, ErrUnknownHeaders, strings.Join(e.Headers, ", "))
+}
+
+// Unwrap 返回 ErrUnknownHeaders
+func (e *UnknownHeadersError) Unwrap() error {
+	return ErrUnknownHeaders
+}
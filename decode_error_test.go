@@ -0,0 +1,95 @@
+package excel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type errorPolicyCustomer struct {
+	Name string `excel:"name"`
+	Age  int    `excel:"age"`
+	Rank int    `excel:"rank"`
+}
+
+var errorPolicyRows = [][]interface{}{
+	{"name", "age", "rank"},
+	{"a", "1", "1"},
+	{"b", "x", "y"},
+	{"c", "3", "3"},
+	{"d", "4", "z"},
+}
+
+func Test_ErrorPolicyFailFast(t *testing.T) {
+	f := openTestFile(t, errorPolicyRows)
+
+	var customers []errorPolicyCustomer
+	_, err := f.DecodeAll(&customers)
+
+	var decodeErrs DecodeErrors
+	if !assert.True(t, errors.As(err, &decodeErrs)) {
+		return
+	}
+	if !assert.Len(t, decodeErrs, 1) {
+		return
+	}
+	assert.Equal(t, &DecodeError{
+		Sheet:  defaultSheetName,
+		Row:    3,
+		Col:    "B",
+		Header: "age",
+		Value:  "x",
+		Err:    decodeErrs[0].Err,
+	}, decodeErrs[0])
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	assert.Equal(t, []errorPolicyCustomer{{Name: "a", Age: 1, Rank: 1}}, customers)
+}
+
+func Test_ErrorPolicySkipRow(t *testing.T) {
+	f := openTestFile(t, errorPolicyRows)
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var customers []errorPolicyCustomer
+	count, err := f.DecodeAll(&customers)
+
+	var decodeErrs DecodeErrors
+	if !assert.True(t, errors.As(err, &decodeErrs)) {
+		return
+	}
+	cells := make([]string, 0, len(decodeErrs))
+	for _, e := range decodeErrs {
+		cells = append(cells, e.Col+strconv.Itoa(e.Row))
+	}
+	assert.Equal(t, []string{"B3", "C3", "C5"}, cells)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []errorPolicyCustomer{
+		{Name: "a", Age: 1, Rank: 1},
+		{Name: "c", Age: 3, Rank: 3},
+	}, customers)
+}
+
+func Test_ErrorPolicyKeepZero(t *testing.T) {
+	f := openTestFile(t, errorPolicyRows)
+	f.SetErrorPolicy(ErrorPolicyKeepZero)
+
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var customers []errorPolicyCustomer
+	err = c.Decode(&customers)
+
+	var decodeErrs DecodeErrors
+	if !assert.True(t, errors.As(err, &decodeErrs)) {
+		return
+	}
+	assert.Len(t, decodeErrs, 3)
+	assert.Equal(t, []errorPolicyCustomer{
+		{Name: "a", Age: 1, Rank: 1},
+		{Name: "b"},
+		{Name: "c", Age: 3, Rank: 3},
+		{Name: "d", Age: 4},
+	}, customers)
+}
@@ -0,0 +1,174 @@
+package excel
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure/tag"
+)
+
+// enum 显示文本与 Go 值之间的映射, 通过 tag 的 enum 选项引用, 如 `excel:"性别,enum=gender"`
+type enum struct {
+	name        string
+	label2Value map[string]interface{} // 显示文本 -> 值
+	value2Label map[interface{}]string // 值的 enumKey -> 显示文本
+	labels      []string               // 所有显示文本, 按字典序排列
+}
+
+func newEnum(name string, label2Value map[string]interface{}) (e *enum) {
+	e = &enum{
+		name:        name,
+		label2Value: make(map[string]interface{}, len(label2Value)),
+		value2Label: make(map[interface{}]string, len(label2Value)),
+		labels:      make([]string, 0, len(label2Value)),
+	}
+	for label, value := range label2Value {
+		e.label2Value[label] = value
+		e.labels = append(e.labels, label)
+	}
+	sort.Strings(e.labels)
+
+	// 多个显示文本对应同一个值时, 写入字典序最小的显示文本
+	for i := len(e.labels) - 1; i >= 0; i-- {
+		label := e.labels[i]
+		e.value2Label[enumKey(e.label2Value[label])] = label
+	}
+	return
+}
+
+// parser 生成将显示文本解析为 t 类型的值的解析器, t 为字段的类型
+//
+// 值为 nil 时解析为 t 的零值, 即指针字段保持 nil; 否则值的类型与 t 所指向的类型不同时,
+// 只在数值类型之间或底层类型相同时转换, 指针字段的值由 buildOneElem 包装为指针
+func (e *enum) parser(t reflect.Type) (parser internalFieldParser) {
+	elemType := t
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	parser = func(valueStr string, col int, row int) (value reflect.Value, err error) {
+		v, ok := e.label2Value[valueStr]
+		if !ok {
+			err = errors.WithMessagef(
+				ErrEnumLabelNotFound,
+				"enum: %s, label: %s, allowed: %s",
+				e.name,
+				valueStr,
+				strings.Join(e.labels, ", "),
+			)
+			err = errors.WithStack(err)
+			return
+		}
+		if v == nil {
+			value = reflect.Zero(t)
+			return
+		}
+
+		value = reflect.ValueOf(v)
+		if value.Type() == elemType {
+			return
+		}
+		if !enumConvertible(value.Type(), elemType) {
+			err = errors.WithMessagef(
+				ErrEnumValueNotConvertible,
+				"enum: %s, label: %s, value %v can not be converted to %s",
+				e.name,
+				valueStr,
+				v,
+				elemType.String(),
+			)
+			err = errors.WithStack(err)
+			return
+		}
+		value = value.Convert(elemType)
+		return
+	}
+	return
+}
+
+// enumConvertible 枚举值能否转换为 t 类型, 只允许数值类型之间或底层类型相同的转换,
+// 避免 reflect 将整数转换为对应码点的字符串
+func enumConvertible(from reflect.Type, t reflect.Type) bool {
+	if isNumberKind(from.Kind()) && isNumberKind(t.Kind()) {
+		return true
+	}
+	return from.Kind() == t.Kind() && from.ConvertibleTo(t)
+}
+
+// isNumberKind 是否为整数或浮点数类型
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// enumKey 获取值在 value2Label 中的键, 使注册的字面量与字段中具名类型的值对应同一个键
+//
+// 数值类型可以表示为整数时统一为 int64, 否则为 uint64 或 float64; 其他类型使用其底层类型的值,
+// 不使用 fmt.Stringer 的结果. nil 与 nil 指针的键为 nil
+func enumKey(value interface{}) (key interface{}) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := v.Uint(); n <= math.MaxInt64 {
+			return int64(n)
+		}
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f)
+		}
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	}
+	if v.Type().Comparable() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// label 获取值对应的显示文本
+func (e *enum) label(value interface{}) (label string, err error) {
+	label, ok := e.value2Label[enumKey(value)]
+	if !ok {
+		err = errors.WithMessagef(ErrEnumValueNotFound, "enum: %s, value: %v", e.name, value)
+		err = errors.WithStack(err)
+	}
+	return
+}
+
+// getEnum 从 enums 中获取 tag 的 enum 选项引用的枚举, 没有 enum 选项时 found 为 false
+func getEnum(enums map[string]*enum, opts tag.Options) (e *enum, found bool, err error) {
+	name, found := opts.Get("enum")
+	if !found {
+		return
+	}
+	e, ok := enums[name]
+	if !ok {
+		err = errors.WithMessage(ErrEnumNotFound, name)
+		err = errors.WithStack(err)
+	}
+	return
+}
@@ -0,0 +1,145 @@
+package excel
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Enum(t *testing.T) {
+	type Customer4Enum struct {
+		Name   string `excel:"name"`
+		Gender int8   `excel:"gender,enum=gender"`
+		Status *int   `excel:"status,enum=status"`
+	}
+	register := func(f *File) {
+		f.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+		f.RegisterEnum("status", map[string]interface{}{"启用": 1, "停用": 0, "正常": 1})
+	}
+	enabled, disabled := 1, 0
+	customers := []Customer4Enum{
+		{Name: "a", Gender: 1, Status: &enabled},
+		{Name: "b", Gender: 2, Status: &disabled},
+		{Name: "c", Gender: 2},
+	}
+
+	// 写入时将值映射为显示文本
+	w := NewFile()
+	register(w)
+	if !assert.NoError(t, w.Write(customers)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{
+			{"name", "gender", "status"},
+			{"a", "男", "启用"},
+			{"b", "女", "停用"},
+			{"c", "女", ""},
+		}, rows)
+	}
+
+	// 解码时将显示文本映射为值
+	register(f)
+	var decoded []Customer4Enum
+	if assert.NoError(t, f.Decode(&decoded)) {
+		assert.Equal(t, customers, decoded)
+	}
+
+	// 未知的显示文本列出所有可用的显示文本
+	f = openTestFile(t, [][]interface{}{{"name", "gender"}, {"a", "未知"}})
+	register(f)
+	err = f.Decode(&decoded)
+	assert.True(t, errors.Is(err, ErrEnumLabelNotFound))
+	assert.Contains(t, err.Error(), "allowed: 女, 男")
+
+	// 枚举没有注册
+	f = openTestFile(t, [][]interface{}{{"name", "gender"}, {"a", "男"}})
+	err = f.Decode(&decoded)
+	assert.True(t, errors.Is(err, ErrEnumNotFound))
+
+	// 值没有对应的显示文本
+	w = NewFile()
+	register(w)
+	err = w.Write([]Customer4Enum{{Name: "d", Gender: 3}})
+	assert.True(t, errors.Is(err, ErrEnumValueNotFound))
+
+	// 值不能转换为字段的类型, 整数不会被转换为字符串
+	type Customer4EnumString struct {
+		Grade string `excel:"grade,enum=grade"`
+	}
+	f = openTestFile(t, [][]interface{}{{"grade"}, {"A"}})
+	f.RegisterEnum("grade", map[string]interface{}{"A": 65})
+	var grades []Customer4EnumString
+	err = f.Decode(&grades)
+	assert.True(t, errors.Is(err, ErrEnumValueNotConvertible))
+	assert.Contains(t, err.Error(), "can not be converted to string")
+}
+
+// gender4Enum 实现了 fmt.Stringer 的具名枚举类型
+type gender4Enum int
+
+func (g gender4Enum) String() string {
+	if g == 1 {
+		return "male"
+	}
+	return "female"
+}
+
+func Test_EnumNamedTypeAndNil(t *testing.T) {
+	type Customer4EnumNamed struct {
+		Gender gender4Enum `excel:"gender,enum=gender"`
+		Status *int        `excel:"status,enum=status"`
+		Score  float64     `excel:"score,enum=score"`
+	}
+	register := func(f *File) {
+		f.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+		f.RegisterEnum("status", map[string]interface{}{"启用": 1, "无": nil})
+		f.RegisterEnum("score", map[string]interface{}{"及格": 60, "满分": uint8(100)})
+	}
+	enabled := 1
+	customers := []Customer4EnumNamed{
+		{Gender: 1, Status: &enabled, Score: 60},
+		{Gender: 2, Score: 100},
+	}
+
+	// 写入时按底层类型的值映射, 不使用 String 的结果
+	w := NewFile()
+	register(w)
+	if !assert.NoError(t, w.Write(customers)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{
+			{"gender", "status", "score"},
+			{"男", "启用", "及格"},
+			{"女", "", "满分"},
+		}, rows)
+	}
+
+	// 值为 nil 的显示文本使指针字段保持 nil
+	f = openTestFile(t, [][]interface{}{{"gender", "status", "score"}, {"男", "启用", "及格"}, {"女", "无", "满分"}})
+	register(f)
+	var decoded []Customer4EnumNamed
+	if assert.NoError(t, f.Decode(&decoded)) {
+		assert.Equal(t, customers, decoded)
+	}
+}
@@ -22,4 +22,28 @@ var (
 	ErrTagNotFound = errors.New("tag not found")
 	// ErrExcelHeaderNotFound excel 中不存在表头
 	ErrExcelHeaderNotFound = errors.New("excel header not found")
+	// ErrBatchSizeInvalid 批量解析的批次大小必须大于 0
+	ErrBatchSizeInvalid = errors.New("batch size invalid")
+	// ErrHeaderAliasConflict 同一字段的多个别名同时出现在 excel 表头中
+	ErrHeaderAliasConflict = errors.New("header alias conflict")
+	// ErrHeaderNormalizeConflict excel 中不同的表头规范化后相同
+	ErrHeaderNormalizeConflict = errors.New("header normalize conflict")
+	// ErrMissingHeaders excel 中缺少必需的表头
+	ErrMissingHeaders = errors.New("missing headers")
+	// ErrUnknownHeaders excel 中存在没有对应字段的表头
+	ErrUnknownHeaders = errors.New("unknown headers")
+	// ErrTagOptionInvalid tag 选项的值不合法
+	ErrTagOptionInvalid = errors.New("tag option invalid")
+	// ErrEmptyCell 单元格为空, 但字段不允许为空
+	ErrEmptyCell = errors.New("empty cell")
+	// ErrValidationFailed 字段值不满足 tag 中的校验规则
+	ErrValidationFailed = errors.New("validation failed")
+	// ErrEnumNotFound tag 中引用的枚举没有注册
+	ErrEnumNotFound = errors.New("enum not found")
+	// ErrEnumLabelNotFound 单元格的值不是枚举的显示文本
+	ErrEnumLabelNotFound = errors.New("enum label not found")
+	// ErrEnumValueNotFound 字段的值没有对应的枚举显示文本
+	ErrEnumValueNotFound = errors.New("enum value not found")
+	// ErrEnumValueNotConvertible 枚举的值不能转换为字段的类型
+	ErrEnumValueNotConvertible = errors.New("enum value not convertible")
 )
@@ -38,10 +38,17 @@ func Test_BuildFile(t *testing.T) {
 	if !assert.NoError(t, err) {
 		return
 	}
-	_ = built.SaveAs("testcase/text.xlsx")
 
-	// todo 此处尚有问题，拿不到builtCols数据
-	builtCols, err := built.GetCols(defaultSheetName)
+	// 流式写入的数据在序列化时才会落入 sheet, 需要重新打开后读取
+	buf, err := built.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	reopened, err := excelize.OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	builtCols, err := reopened.GetCols(defaultSheetName)
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -59,3 +66,93 @@ func Test_BuildFile(t *testing.T) {
 	// 对比是否正确
 	assert.EqualValues(t, expectedCols, builtCols)
 }
+
+func Test_BuildFileWithNestedStruct(t *testing.T) {
+	type Audit4Nested struct {
+		Creator string `excel:"创建人"`
+	}
+	type Address4Nested struct {
+		City   string `excel:"城市"`
+		Street string `excel:"街道"`
+	}
+	type Order4Nested struct {
+		ID string `excel:"订单号"`
+		*Audit4Nested
+		Shipping Address4Nested `excel:",prefix=收货地址-"`
+	}
+	os := []Order4Nested{
+		{ID: "1", Audit4Nested: &Audit4Nested{Creator: "小王"}, Shipping: Address4Nested{City: "上海", Street: "南京路"}},
+		{ID: "2", Shipping: Address4Nested{City: "北京"}},
+	}
+
+	built, err := BuildFile(os)
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := built.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, [][]string{
+		{"订单号", "创建人", "收货地址-城市", "收货地址-街道"},
+		{"1", "小王", "上海", "南京路"},
+		{"2", "", "北京", ""},
+	}, rows)
+
+	var decoded []Order4Nested
+	err = f.Decode(&decoded)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// 解码时嵌入的结构体指针会被自动分配
+	os[1].Audit4Nested = &Audit4Nested{}
+	assert.Equal(t, os, decoded)
+}
+
+type audit4Shadow struct {
+	Creator string `excel:"创建人"`
+}
+
+func Test_BuildFileWithShadowedField(t *testing.T) {
+	type Base4Shadow struct {
+		Name string `excel:"名称"`
+	}
+	type Row4Shadow struct {
+		Base4Shadow
+		Name string `excel:"名称"`
+		*audit4Shadow
+	}
+
+	// 外层字段覆盖嵌入结构体的同名字段
+	built, err := BuildFile([]Row4Shadow{{Base4Shadow: Base4Shadow{Name: "inner"}, Name: "outer"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := built.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{{"名称"}, {"outer"}}, rows)
+	}
+
+	// 未导出的嵌入结构体指针不会被展开, 解码时不会 panic
+	var decoded []Row4Shadow
+	if assert.NoError(t, f.Decode(&decoded)) && assert.Len(t, decoded, 1) {
+		assert.Equal(t, "outer", decoded[0].Name)
+		assert.Nil(t, decoded[0].audit4Shadow)
+	}
+}
@@ -0,0 +1,7 @@
+package excel
+
+// FieldFormatter 字段格式化器, 将字段的值转换为写入单元格的值
+//
+// 指针字段会先被解引用, nil 指针直接对应空单元格, 不会触发格式化器.
+// 返回的 cell 可以是 excelize 支持写入的任意类型, 如 string, int, float64, time.Time 等
+type FieldFormatter func(value interface{}, col int, row int) (cell interface{}, err error)
@@ -3,12 +3,17 @@ package excel
 import (
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
 	"github.com/pkg/errors"
 )
 
 type (
 	// FieldParser 字段解析器
+	//
+	// 返回 nil 表示没有值, 字段保持零值, 指针字段保持 nil
 	FieldParser func(valueStr string, col int, row int) (value interface{}, err error)
 	// internalFieldParser 内部字段解析器
 	//
@@ -32,6 +37,26 @@ func fieldParser2internalFieldParser(p FieldParser) (ip internalFieldParser) {
 	return
 }
 
+// elemParser2ptrParser 将指向类型的内部字段解析器包装为指针类型的内部字段解析器
+func elemParser2ptrParser(p internalFieldParser, ptrType reflect.Type) (ip internalFieldParser) {
+	ip = func(valueStr string, col int, row int) (value reflect.Value, err error) {
+		elemValue, err := p(valueStr, col, row)
+		if err != nil {
+			return
+		}
+		if !elemValue.IsValid() {
+			// 解析器没有返回值, 指针保持 nil
+			value = reflect.Zero(ptrType)
+			return
+		}
+
+		value = reflect.New(ptrType.Elem())
+		value.Elem().Set(elemValue)
+		return
+	}
+	return
+}
+
 // 下面是基础类型的默认类型解析器实现
 
 // string kind
@@ -170,3 +195,104 @@ func str2bool(s string, col int, row int) (b interface{}, err error) {
 	}
 	return
 }
+
+// time
+
+// defaultTimeLayouts 未指定 layout 时依次尝试的时间格式
+var defaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/01/02",
+	"2006/1/2",
+	"01-02-06", // excelize 对内置日期格式 mm-dd-yy 的输出
+	"2006年1月2日",
+}
+
+// str2time 将字符串解析为时间
+//
+// 优先使用 layout 解析, layout 为空时依次尝试 defaultTimeLayouts;
+// 均不匹配时将字符串视为 excel 日期序列号(如 44927), date1904 表示 excel 使用 1904 日期系统.
+// 空字符串解析为零值时间, 与写入时零值时间对应空单元格保持一致
+func str2time(s string, layout string, date1904 bool) (t time.Time, err error) {
+	if s == "" {
+		return
+	}
+
+	layouts := defaultTimeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		t, err = time.Parse(l, s)
+		if err == nil {
+			return
+		}
+	}
+
+	serial, serialErr := strconv.ParseFloat(s, 64)
+	if serialErr != nil {
+		err = errors.WithMessagef(err, "str: %s", s)
+		err = errors.WithStack(err)
+		return
+	}
+	t, err = excelize.ExcelDateToTime(serial, date1904)
+	if err != nil {
+		err = errors.WithMessagef(err, "str: %s", s)
+		err = errors.WithStack(err)
+		return
+	}
+
+	return
+}
+
+// str2duration 将字符串解析为时长
+//
+// 支持 Go 时长格式(如 1h30m)、时钟格式(如 1:30:00) 与 excel 中以天为单位的小数(如 0.0625)
+func str2duration(s string, col int, row int) (d interface{}, err error) {
+	d, err = time.ParseDuration(s)
+	if err == nil {
+		return
+	}
+
+	if clock, ok := clock2duration(s); ok {
+		d = clock
+		err = nil
+		return
+	}
+
+	days, daysErr := strconv.ParseFloat(s, 64)
+	if daysErr != nil {
+		err = errors.WithMessagef(err, "str: %s", s)
+		err = errors.WithStack(err)
+		return
+	}
+	d = time.Duration(days * float64(24*time.Hour))
+	err = nil
+	return
+}
+
+// clock2duration 解析 时:分 或 时:分:秒 格式的时长, 小时可以超过 24
+func clock2duration(s string) (d time.Duration, ok bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return
+	}
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return
+		}
+		d += time.Duration(n * float64(units[i]))
+	}
+
+	ok = true
+	return
+}
@@ -2,6 +2,7 @@ package excel
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -200,3 +201,71 @@ func Test_str2bool(t *testing.T) {
 	_, err = str2bool("hello", 0, 0)
 	assert.Error(t, err)
 }
+
+// time
+func Test_str2time(t *testing.T) {
+	expected := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, s := range []string{"2023-01-01", "2023/1/1", "01-01-23", "44927", "2023-01-01T00:00:00Z"} {
+		tm, err := str2time(s, "", false)
+		if !assert.NoError(t, err, s) {
+			return
+		}
+		if !assert.True(t, expected.Equal(tm), "%s: %s", s, tm) {
+			return
+		}
+	}
+
+	// 1904 日期系统
+	tm, err := str2time("43465", "", true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.True(t, expected.Equal(tm), tm.String()) {
+		return
+	}
+
+	// 指定 layout
+	tm, err = str2time("01.01.2023", "02.01.2006", false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.True(t, expected.Equal(tm), tm.String()) {
+		return
+	}
+
+	tm, err = str2time("", "", false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.True(t, tm.IsZero()) {
+		return
+	}
+
+	_, err = str2time("2023-13-01", "", false)
+	if !assert.Error(t, err) {
+		return
+	}
+}
+
+func Test_str2duration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"1h30m":    90 * time.Minute,
+		"1:30":     90 * time.Minute,
+		"25:00:30": 25*time.Hour + 30*time.Second,
+		"0.0625":   90 * time.Minute,
+	} {
+		d, err := str2duration(s, 0, 0)
+		if !assert.NoError(t, err, s) {
+			return
+		}
+		if !assert.Equal(t, expected, d, s) {
+			return
+		}
+	}
+
+	_, err := str2duration("1a", 0, 0)
+	if !assert.Error(t, err) {
+		return
+	}
+}
@@ -2,10 +2,12 @@ package excel
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 
 	"github.com/360EntSecGroup-Skylar/excelize/v2"
 	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure"
 )
 
 const (
@@ -18,13 +20,31 @@ const (
 
 // File 打开的 excel 文件
 type File struct {
-	sheetName         string         // 目标 sheetName
-	headersSet        [][]string     // 被手动设置的表头列表
-	headerIndex       map[string]int // 被手动设置的表头索引, 此索引优先级高于从 excel 中自动解析出的索引
-	ef                *excelize.File
-	maxDecodeAllCount int                                  // DecodeAll 支持的最大数据条数
-	typeParsers       map[reflect.Type]internalFieldParser // 类型解析器, 其优先级低于 tagParsers
-	tagParsers        map[string]internalFieldParser       // tag 解析器, 其优先级高于 typeParsers
+	sheetName             string         // 目标 sheetName
+	headersSet            [][]string     // 被手动设置的表头列表
+	headerIndex           map[string]int // 被手动设置的表头索引, 此索引优先级高于从 excel 中自动解析出的索引
+	ef                    *excelize.File
+	maxDecodeAllCount     int                                  // DecodeAll 支持的最大数据条数
+	typeParsers           map[reflect.Type]internalFieldParser // 类型解析器, 其优先级低于 tagParsers
+	tagParsers            map[string]internalFieldParser       // tag 解析器, 其优先级高于 typeParsers
+	typeFormatters        map[reflect.Type]FieldFormatter      // 类型格式化器, 其优先级低于 tagFormatters
+	tagFormatters         map[string]FieldFormatter            // tag 格式化器, 其优先级高于 typeFormatters
+	enums                 map[string]*enum                     // 已注册的枚举, 通过 tag 的 enum 选项引用
+	errorPolicy           ErrorPolicy                          // 字段解析出错时的处理策略
+	progressHandler       ProgressHandler                      // 读写进度回调
+	parallelism           int                                  // 并行解码的 worker 数量, 不大于 1 时逐行解码
+	headerNormalizeOption HeaderNormalizeOption                // 表头规范化选项
+	headerNormalizer      HeaderNormalizer                     // 自定义的表头规范化函数, 在规范化选项之后执行
+	strict                bool                                 // 严格模式, excel 中的每个表头都必须有对应的字段
+	blankRowPolicy        BlankRowPolicy                       // 遇到空白行时的处理策略
+	endOfTable            EndOfTableFunc                       // 判断表格结尾的函数
+	headerRow             int                                  // 表头所在的行, 从 1 开始, 不大于 0 时为第一行
+	headerRowCount        int                                  // 表头所占的行数, 不大于 1 时为单行表头
+	dataStartRow          int                                  // 第一个数据行, 从 1 开始, 位于表头之内时为表头的下一行
+	detectType            reflect.Type                         // 自动检测表头时匹配的目标类型, 为 nil 时不检测
+	detectRows            int                                  // 自动检测表头时扫描的行数
+	validationRows        int                                  // 写入时数据有效性规则覆盖的行数, 不大于 0 时不生成规则
+	groupedHeaders        bool                                 // 写入时是否将路径形式的表头拆分为多行分组表头
 }
 
 func newFile(ef *excelize.File) (f *File) {
@@ -34,6 +54,9 @@ func newFile(ef *excelize.File) (f *File) {
 		maxDecodeAllCount: defaultMaxDecodeAllCount,
 		typeParsers:       make(map[reflect.Type]internalFieldParser),
 		tagParsers:        make(map[string]internalFieldParser),
+		typeFormatters:    make(map[reflect.Type]FieldFormatter),
+		tagFormatters:     make(map[string]FieldFormatter),
+		enums:             make(map[string]*enum),
 	}
 }
 
@@ -81,6 +104,96 @@ func (f *File) SetMaxDecodeAllCount(max int) {
 	f.maxDecodeAllCount = max
 }
 
+// SetErrorPolicy 设置字段解析出错时的处理策略, 默认为 ErrorPolicyFailFast
+func (f *File) SetErrorPolicy(p ErrorPolicy) {
+	f.errorPolicy = p
+}
+
+// OnProgress 设置进度回调, 对之后生成的 Cursor 与 Stream 生效
+func (f *File) OnProgress(h ProgressHandler) {
+	f.progressHandler = h
+}
+
+// SetParallelism 设置并行解码的 worker 数量, 对之后生成的 Cursor 生效, 详见 Cursor.SetParallelism
+func (f *File) SetParallelism(n int) {
+	f.parallelism = n
+}
+
+// SetHeaderNormalizeOption 设置表头规范化选项, 解析时 excel 表头与结构体 tag 规范化后相同即视为匹配
+func (f *File) SetHeaderNormalizeOption(o HeaderNormalizeOption) {
+	f.headerNormalizeOption = o
+}
+
+// SetHeaderNormalizer 设置自定义的表头规范化函数, 在规范化选项之后执行
+func (f *File) SetHeaderNormalizer(h HeaderNormalizer) {
+	f.headerNormalizer = h
+}
+
+// SetHeaderRow 设置表头所在的行, 从 1 开始, 默认为第一行
+func (f *File) SetHeaderRow(n int) {
+	f.headerRow = n
+}
+
+// SetHeaderRowCount 设置表头所占的行数, 默认为 1
+//
+// 多行表头的各行会被合并为路径形式, 如 "联系人" 横向合并了 "姓名" 与 "电话" 的上方单元格时,
+// 这两列的表头为 "联系人/姓名" 与 "联系人/电话", 可以通过 `excel:"联系人/电话"` 引用
+func (f *File) SetHeaderRowCount(n int) {
+	f.headerRowCount = n
+}
+
+// SetDataStartRow 设置第一个数据行, 从 1 开始, 默认为表头的下一行, 位于表头之内时同样使用表头的下一行
+//
+// 表头与数据之间存在说明行时使用
+func (f *File) SetDataStartRow(n int) {
+	f.dataStartRow = n
+}
+
+// SetHeaderDetection 开启表头自动检测, 在前 maxRows 行中选取与 elem 类型的 tag 匹配最多的行作为表头,
+// 匹配数相同时取靠前的行, 没有任何行匹配时返回 ErrExcelHeaderNotFound. 开启后 SetHeaderRow 不再生效
+//
+// elem 为目标元素或其指针, 为 nil 时关闭自动检测. 适用于表格上方存在标题与说明的文件
+func (f *File) SetHeaderDetection(elem interface{}, maxRows int) {
+	f.detectType = nil
+	if elem != nil {
+		f.detectType = structure.TypeTry2Elem(reflect.TypeOf(elem))
+	}
+	f.detectRows = maxRows
+}
+
+// SetStrict 设置严格模式, 对之后生成的 Cursor 生效, 详见 Cursor.SetStrict
+func (f *File) SetStrict(strict bool) {
+	f.strict = strict
+}
+
+// SetBlankRowPolicy 设置遇到空白行时的处理策略, 对之后生成的 Cursor 生效, 默认为 BlankRowKeep
+//
+// 对所有解码方法以及 DecodeAll 的数据总量检查均生效
+func (f *File) SetBlankRowPolicy(p BlankRowPolicy) {
+	f.blankRowPolicy = p
+}
+
+// SetEndOfTable 设置判断表格结尾的函数, 对之后生成的 Cursor 生效, 详见 Cursor.SetEndOfTable
+//
+// 对所有解码方法以及 DecodeAll 的数据总量检查均生效
+func (f *File) SetEndOfTable(fn EndOfTableFunc) {
+	f.endOfTable = fn
+}
+
+// ValidateHeaders 在解码任何数据行之前, 检查 excel 的表头是否可以解码为 elem 的类型
+//
+// elem 为目标元素或其指针. 缺少带有 required 选项的表头时返回 MissingHeadersError,
+// 严格模式下存在没有对应字段的表头时返回 UnknownHeadersError.
+// 解码时也会进行同样的检查, 且同样在解码第一行之前报告
+func (f *File) ValidateHeaders(elem interface{}) (err error) {
+	c, err := f.Cursor()
+	if err != nil {
+		return
+	}
+	_, err = c.getFields(structure.TypeTry2Elem(reflect.TypeOf(elem)))
+	return
+}
+
 // RegisterTypeParser 注册类型解析器
 func (f *File) RegisterTypeParser(elem interface{}, parser FieldParser) {
 	t := reflect.TypeOf(elem)
@@ -94,23 +207,65 @@ func (f *File) RegisterTagParser(excelTag string, parser FieldParser) {
 	f.tagParsers[excelTag] = internalParser
 }
 
+// RegisterEnum 注册枚举, labels 为显示文本与 Go 值的映射, 同名枚举会被覆盖
+//
+// tag 中通过 enum 选项引用, 如 `excel:"性别,enum=gender"`. 解码时将显示文本映射为值, 值的类型与字段不同时,
+// 只在数值类型之间或底层类型相同时转换, 否则报告 ErrEnumValueNotConvertible; 值为 nil 时解析为零值, 指针字段保持 nil.
+// 未知的显示文本报告 ErrEnumLabelNotFound 并列出所有可用的显示文本; 写入时将值映射为显示文本, 值按底层类型比较,
+// 如实现了 fmt.Stringer 的具名整数类型与注册的整数相同时对应同一个显示文本,
+// 多个显示文本对应同一个值时使用字典序最小的显示文本. 枚举的优先级低于 tag 解析器与 tag 格式化器
+func (f *File) RegisterEnum(name string, labels map[string]interface{}) {
+	f.enums[name] = newEnum(name, labels)
+}
+
+// SetValidationRows 设置写入时数据有效性规则覆盖的行数, 对之后生成的 Stream 生效, 详见 Stream.SetValidationRows
+func (f *File) SetValidationRows(n int) {
+	f.validationRows = n
+}
+
+// SetGroupedHeaders 设置写入时是否将路径形式的表头拆分为多行分组表头, 对之后生成的 Stream 生效, 详见 Stream.SetGroupedHeaders
+func (f *File) SetGroupedHeaders(grouped bool) {
+	f.groupedHeaders = grouped
+}
+
+// RegisterTypeFormatter 注册类型格式化器
+func (f *File) RegisterTypeFormatter(elem interface{}, formatter FieldFormatter) {
+	t := reflect.TypeOf(elem)
+	f.typeFormatters[t] = formatter
+}
+
+// RegisterTagFormatter 注册字段格式化器
+func (f *File) RegisterTagFormatter(excelTag string, formatter FieldFormatter) {
+	f.tagFormatters[excelTag] = formatter
+}
+
 // Decode 解析
 func (f *File) Decode(elems interface{}) (err error) {
+	return f.DecodeContext(context.Background(), elems)
+}
+
+// DecodeContext 与 Decode 相同, 每行解码前检查 ctx 是否已取消
+func (f *File) DecodeContext(ctx context.Context, elems interface{}) (err error) {
 	c, err := f.Cursor()
 	if err != nil {
 		return
 	}
-	return c.Decode(elems)
+	return c.DecodeContext(ctx, elems)
 }
 
 // DecodeMany 批量解析
 func (f *File) DecodeMany(elems interface{}, limit int) (count int, err error) {
+	return f.DecodeManyContext(context.Background(), elems, limit)
+}
+
+// DecodeManyContext 与 DecodeMany 相同, 每行解码前检查 ctx 是否已取消
+func (f *File) DecodeManyContext(ctx context.Context, elems interface{}, limit int) (count int, err error) {
 	c, err := f.Cursor()
 	if err != nil {
 		return
 	}
 
-	count, err = c.DecodeMany(elems, limit)
+	count, err = c.DecodeManyContext(ctx, elems, limit)
 
 	return
 }
@@ -120,13 +275,20 @@ func (f *File) DecodeMany(elems interface{}, limit int) (count int, err error) {
 // 为防止内存占用过大, DecodeAll 的 count 最大值受 maxDecodeAllCount 控制.
 // 如果需要修改, 可调用 SetMaxDecodeAllCount 方法
 func (f *File) DecodeAll(elems interface{}) (count int, err error) {
+	return f.DecodeAllContext(context.Background(), elems)
+}
+
+// DecodeAllContext 与 DecodeAll 相同, 每行解码前检查 ctx 是否已取消
+func (f *File) DecodeAllContext(ctx context.Context, elems interface{}) (count int, err error) {
 	c, err := f.Cursor()
 	if err != nil {
 		return
 	}
 
-	count, err = c.DecodeMany(elems, f.maxDecodeAllCount)
-	if err != nil {
+	count, err = c.DecodeManyContext(ctx, elems, f.maxDecodeAllCount)
+	// 按策略收集的单元格错误不影响数据总量的检查
+	var decodeErrs DecodeErrors
+	if err != nil && (f.errorPolicy == ErrorPolicyFailFast || !errors.As(err, &decodeErrs)) {
 		return
 	}
 
@@ -137,31 +299,109 @@ func (f *File) DecodeAll(elems interface{}) (count int, err error) {
 		return
 	}
 
+	if len(decodeErrs) > 0 {
+		err = decodeErrs
+	}
+
+	return
+}
+
+// DecodeBatches 按批次解码数据并回调 fn, 详见 Cursor.DecodeBatches
+func (f *File) DecodeBatches(
+	elemsPtr interface{},
+	batchSize int,
+	fn func(batch interface{}, firstRow int, lastRow int) error,
+) (
+	err error,
+) {
+	return f.DecodeBatchesContext(context.Background(), elemsPtr, batchSize, fn)
+}
+
+// DecodeBatchesContext 与 DecodeBatches 相同, 每行解码前检查 ctx 是否已取消
+func (f *File) DecodeBatchesContext(
+	ctx context.Context,
+	elemsPtr interface{},
+	batchSize int,
+	fn func(batch interface{}, firstRow int, lastRow int) error,
+) (
+	err error,
+) {
+	c, err := f.Cursor()
+	if err != nil {
+		return
+	}
+
+	err = c.DecodeBatchesContext(ctx, elemsPtr, batchSize, fn)
+
+	return
+}
+
+// Each 逐行解码并回调 fn, fn 返回错误时停止迭代并原样返回该错误, 详见 Cursor.Each
+func (f *File) Each(elemPtr interface{}, fn func(row int, elemPtr interface{}) error) (err error) {
+	return f.EachContext(context.Background(), elemPtr, fn)
+}
+
+// EachContext 与 Each 相同, 每行解码前检查 ctx 是否已取消
+func (f *File) EachContext(
+	ctx context.Context,
+	elemPtr interface{},
+	fn func(row int, elemPtr interface{}) error,
+) (
+	err error,
+) {
+	c, err := f.Cursor()
+	if err != nil {
+		return
+	}
+
+	err = c.EachContext(ctx, elemPtr, fn)
+
 	return
 }
 
 // Cursor 获取迭代器
 func (f *File) Cursor() (c *Cursor, err error) {
-	// 解析 sheet 的第一行, 建立表头索引
-	headerIndex, err := f.buildHeaderIndex()
+	// 解析 sheet 的表头行, 建立表头索引
+	headerRow, headerIndex, err := f.buildHeaderIndex()
 	if err != nil {
 		return
 	}
 	// 相比解析 excel 自动生成的表头索引, 手动设置的表头索引拥有更高优先级
 	for header, index := range f.headerIndex {
-		headerIndex[header] = index
+		headerIndex[f.normalizeHeader(header)] = index
 	}
 
 	// 获取行式流式迭代器
+	sheetName, err := f.GetSheetName()
+	if err != nil {
+		return
+	}
 	rows, err := f.getRows()
 	if err != nil {
 		return
 	}
-	// 跳过表头行
-	rows.Next()
-	rows.Columns()
+	// 跳过第一个数据行之前的所有行, excelize 要求每行都读取过才能正确读取下一行
+	dataStartRow := f.dataStartRow
+	if dataStartRow < headerRow+f.getHeaderRowCount() {
+		dataStartRow = headerRow + f.getHeaderRowCount()
+	}
+	for i := 1; i < dataStartRow && rows.Next(); i++ {
+		_, err = rows.Columns()
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
 
-	c = newCursor(headerIndex, rows)
+	c = newCursor(f.ef, sheetName, headerIndex, rows, dataStartRow-1)
+	c.SetErrorPolicy(f.errorPolicy)
+	c.OnProgress(f.progressHandler)
+	c.SetParallelism(f.parallelism)
+	c.headerNormalizer = f.normalizeHeader
+	c.SetStrict(f.strict)
+	c.SetBlankRowPolicy(f.blankRowPolicy)
+	c.SetEndOfTable(f.endOfTable)
+	c.date1904 = f.isDate1904()
 
 	// 写入解析器
 	for t, p := range f.typeParsers {
@@ -170,6 +410,9 @@ func (f *File) Cursor() (c *Cursor, err error) {
 	for t, p := range f.tagParsers {
 		c.registerTagParser(t, p)
 	}
+	for _, e := range f.enums {
+		c.registerEnum(e)
+	}
 
 	return
 }
@@ -215,8 +458,28 @@ func (f *File) Stream(sheetNames ...string) (s *Stream, err error) {
 	}
 
 	s = &Stream{
-		headersSet: f.headersSet,
-		sw:         sw,
+		headersSet:     f.headersSet,
+		ef:             f.ef,
+		sw:             sw,
+		sheetName:      sheetName,
+		numFmtStyles:   make(map[string]int),
+		typeFormatters: make(map[reflect.Type]FieldFormatter),
+		tagFormatters:  make(map[string]FieldFormatter),
+		enums:          make(map[string]*enum, len(f.enums)),
+	}
+	s.OnProgress(f.progressHandler)
+	s.SetValidationRows(f.validationRows)
+	s.SetGroupedHeaders(f.groupedHeaders)
+
+	// 写入格式化器
+	for t, formatter := range f.typeFormatters {
+		s.typeFormatters[t] = formatter
+	}
+	for t, formatter := range f.tagFormatters {
+		s.tagFormatters[t] = formatter
+	}
+	for name, e := range f.enums {
+		s.enums[name] = e
 	}
 
 	return
@@ -236,6 +499,12 @@ func (f *File) ExportBuffer() (buf *bytes.Buffer, err error) {
 	return
 }
 
+// isDate1904 excel 是否使用 1904 日期系统
+func (f *File) isDate1904() bool {
+	wb := f.ef.WorkBook
+	return wb != nil && wb.WorkbookPr != nil && wb.WorkbookPr.Date1904
+}
+
 func (f *File) getRows() (rows *excelize.Rows, err error) {
 	sheetName, err := f.GetSheetName()
 	if err != nil {
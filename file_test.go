@@ -19,7 +19,7 @@ func Test_DecodeAll(t *testing.T) {
 	}
 
 	f, err := OpenFile("testcase/cursor.xlsx")
-	if assert.Error(t, err) {
+	if !assert.NoError(t, err) {
 		return
 	}
 
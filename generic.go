@@ -0,0 +1,53 @@
+package excel
+
+// DecodeAll 解析所有数据, 是 File.DecodeAll 的泛型版本
+func DecodeAll[T any](f *File) (elems []T, err error) {
+	_, err = f.DecodeAll(&elems)
+	return
+}
+
+// DecodeMany 最多解析 limit 条数据, 是 File.DecodeMany 的泛型版本
+func DecodeMany[T any](f *File, limit int) (elems []T, err error) {
+	_, err = f.DecodeMany(&elems, limit)
+	return
+}
+
+// Write 写入元素列表, 是 File.Write 的泛型版本
+func Write[T any](f *File, elems []T, sheetName ...string) (err error) {
+	err = f.Write(elems, sheetName...)
+	return
+}
+
+// TypedCursor 按行解析 excel 的泛型迭代器
+type TypedCursor[T any] struct {
+	c *Cursor
+}
+
+// NewTypedCursor 获取泛型迭代器
+func NewTypedCursor[T any](f *File) (tc *TypedCursor[T], err error) {
+	c, err := f.Cursor()
+	if err != nil {
+		return
+	}
+
+	tc = &TypedCursor[T]{c: c}
+	return
+}
+
+// Cursor 获取底层的迭代器, 可用于注册解析器与回调
+func (tc *TypedCursor[T]) Cursor() (c *Cursor) {
+	return tc.c
+}
+
+// Next 如果还有下个元素, 返回 true
+func (tc *TypedCursor[T]) Next() bool {
+	return tc.c.Next()
+}
+
+// Value 解码当前行, 每次 Next 之后只能调用一次
+//
+// 单元格解析出错时返回该行所有的 DecodeErrors, 出错的字段保留零值
+func (tc *TypedCursor[T]) Value() (elem T, err error) {
+	err = tc.c.Scan(&elem)
+	return
+}
@@ -0,0 +1,81 @@
+package excel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type customer4Generic struct {
+	Name string `excel:"name"`
+	Age  int    `excel:"age"`
+}
+
+func Test_GenericDecodeAllAndWrite(t *testing.T) {
+	cs := []customer4Generic{
+		{Name: "a", Age: 1},
+		{Name: "b", Age: 2},
+	}
+
+	f := NewFile()
+	err := Write(f, cs)
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	rf, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	decoded, err := DecodeAll[customer4Generic](rf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, cs, decoded)
+
+	decoded, err = DecodeMany[customer4Generic](rf, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, cs[:1], decoded)
+}
+
+func Test_TypedCursor(t *testing.T) {
+	f := openTestFile(t, [][]interface{}{
+		{"name", "age"},
+		{"a", "1"},
+		{"b", "x"},
+		{"c", "3"},
+	})
+
+	tc, err := NewTypedCursor[customer4Generic](f)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var cs []customer4Generic
+	var badCells []string
+	for tc.Next() {
+		c, err := tc.Value()
+		var decodeErrs DecodeErrors
+		if errors.As(err, &decodeErrs) {
+			for _, e := range decodeErrs {
+				badCells = append(badCells, e.Col+strconv.Itoa(e.Row))
+			}
+			continue
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		cs = append(cs, c)
+	}
+
+	assert.Equal(t, []customer4Generic{{Name: "a", Age: 1}, {Name: "c", Age: 3}}, cs)
+	assert.Equal(t, []string{"B3"}, badCells)
+}
@@ -1,48 +1,355 @@
 package excel
 
 import (
+	"strings"
+	"unicode"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
 	"github.com/pkg/errors"
 )
 
-// buildHeaderIndex 建造 excel 表头位置的索引
-func (f *File) buildHeaderIndex() (headerIndex map[string]int, err error) {
+// headerPathSeparator 多行表头中, 各行的表头合并为路径时使用的分隔符
+const headerPathSeparator = "/"
+
+// HeaderNormalizeOption 表头规范化选项, 可以按位组合, 同时作用于 excel 表头与结构体 tag
+type HeaderNormalizeOption int
+
+const (
+	// HeaderHalfWidth 将全角字符转换为半角, 如 "（" -> "(", 全角空格 -> " "
+	HeaderHalfWidth HeaderNormalizeOption = 1 << iota
+	// HeaderStripNewline 去除换行符
+	HeaderStripNewline
+	// HeaderCollapseSpace 将连续的空白字符合并为一个空格
+	HeaderCollapseSpace
+	// HeaderTrimSpace 去除首尾的空白字符
+	HeaderTrimSpace
+	// HeaderFoldCase 转换为小写
+	HeaderFoldCase
+
+	// HeaderNormalizeAll 启用所有规范化选项
+	HeaderNormalizeAll = HeaderHalfWidth | HeaderStripNewline | HeaderCollapseSpace | HeaderTrimSpace | HeaderFoldCase
+)
+
+// HeaderNormalizer 自定义的表头规范化函数, 同时作用于 excel 表头与结构体 tag
+type HeaderNormalizer func(header string) string
+
+// Normalize 按照选项规范化表头, 按常量的定义顺序依次处理
+func (o HeaderNormalizeOption) Normalize(header string) (normalized string) {
+	normalized = header
+	if o&HeaderHalfWidth != 0 {
+		normalized = strings.Map(fullWidth2HalfWidth, normalized)
+	}
+	if o&HeaderStripNewline != 0 {
+		normalized = strings.NewReplacer("\r", "", "\n", "").Replace(normalized)
+	}
+	if o&HeaderCollapseSpace != 0 {
+		normalized = collapseSpace(normalized)
+	}
+	if o&HeaderTrimSpace != 0 {
+		normalized = strings.TrimSpace(normalized)
+	}
+	if o&HeaderFoldCase != 0 {
+		normalized = strings.ToLower(normalized)
+	}
+	return
+}
+
+// fullWidth2HalfWidth 将全角字符转换为对应的半角字符, 其他字符原样返回
+func fullWidth2HalfWidth(r rune) rune {
+	switch {
+	case r == '\u3000':
+		return ' '
+	case r >= '\uFF01' && r <= '\uFF5E':
+		return r - 0xFEE0
+	}
+	return r
+}
+
+// collapseSpace 将连续的空白字符合并为一个空格
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inSpace := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				b.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// normalizeHeader 依次使用规范化选项与自定义的规范化函数处理表头
+func (f *File) normalizeHeader(header string) string {
+	header = f.headerNormalizeOption.Normalize(header)
+	if f.headerNormalizer != nil {
+		header = f.headerNormalizer(header)
+	}
+	return header
+}
+
+// buildHeaderIndex 建造 excel 表头位置的索引, headerRow 为表头所在的行, 从 1 开始
+//
+// 不同的表头规范化后相同时, 无法确定字段对应的列, 返回 ErrHeaderNormalizeConflict
+func (f *File) buildHeaderIndex() (headerRow int, headerIndex map[string]int, err error) {
 	headerIndex = make(map[string]int)
 
 	// 获取表头
-	headers, err := f.GetHeadersFromExcel()
+	headerRow, headers, err := f.readHeaders()
 	if err != nil {
 		return
 	}
 
 	// 获取所有表头的位置索引
 	for i, header := range headers {
-		if header == "" {
+		key := f.normalizeHeader(header)
+		if key == "" {
 			continue
 		}
-		headerIndex[header] = i
+		if j, ok := headerIndex[key]; ok && headers[j] != header {
+			sheetName, _ := f.GetSheetName()
+			err = errors.WithMessagef(
+				ErrHeaderNormalizeConflict,
+				"sheet: %s, headers: %q, %q, normalized: %q",
+				sheetName,
+				headers[j],
+				header,
+				key,
+			)
+			err = errors.WithStack(err)
+			return
+		}
+		headerIndex[key] = i
 	}
 
 	return
 }
 
 // GetHeadersFromExcel 从 excel 读取实际的表头
+//
+// 表头默认位于第一行, 可以通过 SetHeaderRow 指定, 或通过 SetHeaderDetection 自动检测
 func (f *File) GetHeadersFromExcel() (headers []string, err error) {
+	_, headers, err = f.readHeaders()
+	return
+}
+
+// readHeaders 读取表头所在的第一行与表头的内容, 多行表头被合并为路径形式, 如 "联系人/电话"
+func (f *File) readHeaders() (headerRow int, headers []string, err error) {
+	if f.detectType != nil {
+		headerRow, headers, err = f.detectHeaders()
+		return
+	}
+
+	headerRow = f.headerRow
+	if headerRow <= 0 {
+		headerRow = 1
+	}
+
+	rows, err := f.readTopRows(headerRow + f.getHeaderRowCount() - 1)
+	if err != nil {
+		return
+	}
+	if len(rows) < headerRow {
+		err = errors.WithMessagef(ErrExcelHeaderNotFound, "row: %d", headerRow)
+		err = errors.WithStack(err)
+		return
+	}
+	headers, err = f.combineHeaderRows(rows[headerRow-1:], headerRow)
+	return
+}
+
+// detectHeaders 在前 detectRows 行中选取与 detectType 的 tag 匹配最多的行作为表头的第一行, 匹配数相同时取靠前的行
+func (f *File) detectHeaders() (headerRow int, headers []string, err error) {
+	// 规范化所有字段的别名
+	fields := getSchema(f.detectType).Fields
+	aliases := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		normalized := make([]string, 0, len(field.Aliases))
+		for _, alias := range field.Aliases {
+			normalized = append(normalized, f.normalizeHeader(alias))
+		}
+		aliases = append(aliases, normalized)
+	}
+
+	rows, err := f.readTopRows(f.detectRows + f.getHeaderRowCount() - 1)
+	if err != nil {
+		return
+	}
+	best := 0
+	for row := 1; row <= f.detectRows && row <= len(rows); row++ {
+		var cols []string
+		cols, err = f.combineHeaderRows(rows[row-1:], row)
+		if err != nil {
+			return
+		}
+
+		matched := countMatchedFields(aliases, f.normalizeHeaders(cols))
+		if matched > best {
+			best = matched
+			headerRow = row
+			headers = cols
+		}
+	}
+	if best == 0 {
+		err = errors.WithMessagef(
+			ErrExcelHeaderNotFound,
+			"no row matches %s in the first %d rows",
+			f.detectType.String(),
+			f.detectRows,
+		)
+		err = errors.WithStack(err)
+		return
+	}
+
+	return
+}
+
+// readTopRows 读取 sheet 的前 n 行, sheet 不足 n 行时返回所有行
+func (f *File) readTopRows(n int) (rows [][]string, err error) {
+	it, err := f.getRows()
+	if err != nil {
+		return
+	}
+	for i := 0; i < n && it.Next(); i++ {
+		var cols []string
+		cols, err = it.Columns()
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+		rows = append(rows, cols)
+	}
+	return
+}
+
+// getHeaderRowCount 获取表头所占的行数
+func (f *File) getHeaderRowCount() int {
+	if f.headerRowCount <= 1 {
+		return 1
+	}
+	return f.headerRowCount
+}
+
+// combineHeaderRows 将以第 firstRow 行开始的多行表头合并为路径形式, 只使用 rows 的前 headerRowCount 行
+//
+// 合并单元格的值会被填充到其覆盖的每个单元格, 每列自上而下以 "/" 拼接非空的值, 连续重复的值只保留一个.
+// 如 "联系人" 横向合并了 "姓名" 与 "电话" 的上方单元格, 则这两列的表头为 "联系人/姓名" 与 "联系人/电话"
+func (f *File) combineHeaderRows(rows [][]string, firstRow int) (headers []string, err error) {
+	count := f.getHeaderRowCount()
+	if count > len(rows) {
+		count = len(rows)
+	}
+	if count <= 1 {
+		if len(rows) > 0 {
+			headers = rows[0]
+		}
+		return
+	}
+
+	// 复制表头区域, 避免修改 rows
+	grid := make([][]string, count)
+	for i := range grid {
+		grid[i] = append([]string(nil), rows[i]...)
+	}
+
+	// 填充合并单元格
 	sheetName, err := f.GetSheetName()
 	if err != nil {
 		return
 	}
-	rows, err := f.ef.Rows(sheetName)
+	merges, err := f.ef.GetMergeCells(sheetName)
 	if err != nil {
+		err = errors.WithStack(err)
 		return
 	}
-	if !rows.Next() {
-		err = errors.WithStack(ErrExcelHeaderNotFound)
+	for _, merge := range merges {
+		err = fillMergeCell(grid, firstRow, merge)
+		if err != nil {
+			return
+		}
+	}
+
+	// 逐列拼接
+	width := 0
+	for _, row := range grid {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	headers = make([]string, width)
+	for col := range headers {
+		parts := make([]string, 0, count)
+		for _, row := range grid {
+			if col >= len(row) || row[col] == "" {
+				continue
+			}
+			if len(parts) > 0 && parts[len(parts)-1] == row[col] {
+				continue
+			}
+			parts = append(parts, row[col])
+		}
+		headers[col] = strings.Join(parts, headerPathSeparator)
+	}
+
+	return
+}
+
+// fillMergeCell 将合并单元格的值填充到 grid 中被其覆盖的单元格, grid 的第一行对应 excel 的第 firstRow 行
+func fillMergeCell(grid [][]string, firstRow int, merge excelize.MergeCell) (err error) {
+	startCol, startRow, err := excelize.CellNameToCoordinates(merge.GetStartAxis())
+	if err != nil {
+		err = errors.WithStack(err)
 		return
 	}
-	headers, err = rows.Columns()
+	endCol, endRow, err := excelize.CellNameToCoordinates(merge.GetEndAxis())
 	if err != nil {
 		err = errors.WithStack(err)
 		return
 	}
+
+	value := merge.GetCellValue()
+	for row := startRow; row <= endRow; row++ {
+		i := row - firstRow
+		if i < 0 || i >= len(grid) {
+			continue
+		}
+		for len(grid[i]) < endCol {
+			grid[i] = append(grid[i], "")
+		}
+		for col := startCol; col <= endCol; col++ {
+			grid[i][col-1] = value
+		}
+	}
+	return
+}
+
+// normalizeHeaders 规范化一行中所有的表头, 返回规范化后的表头集合
+func (f *File) normalizeHeaders(cols []string) (headers map[string]bool) {
+	headers = make(map[string]bool, len(cols))
+	for _, col := range cols {
+		header := f.normalizeHeader(col)
+		if header == "" {
+			continue
+		}
+		headers[header] = true
+	}
+	return
+}
+
+// countMatchedFields 统计任意一个别名出现在 headers 中的字段数量
+func countMatchedFields(aliases [][]string, headers map[string]bool) (matched int) {
+	for _, fieldAliases := range aliases {
+		for _, alias := range fieldAliases {
+			if headers[alias] {
+				matched++
+				break
+			}
+		}
+	}
 	return
 }
@@ -0,0 +1,270 @@
+package excel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HeaderNormalizeOption(t *testing.T) {
+	cases := []struct {
+		option   HeaderNormalizeOption
+		header   string
+		expected string
+	}{
+		{0, " 手机号 ", " 手机号 "},
+		{HeaderTrimSpace, " 手机号\t", "手机号"},
+		{HeaderCollapseSpace, "联系  人\t电话", "联系 人 电话"},
+		{HeaderFoldCase, "Mobile", "mobile"},
+		{HeaderHalfWidth, "金额（元）　ＡＢ", "金额(元) AB"},
+		{HeaderStripNewline, "联系\r\n电话", "联系电话"},
+		{HeaderNormalizeAll, " 金额\n（ＵＳＤ）  Total ", "金额(usd) total"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.option.Normalize(c.header), c.header)
+	}
+}
+
+func Test_DecodeWithNormalizedHeaders(t *testing.T) {
+	type Customer4Normalize struct {
+		Name   string  `excel:"Name"`
+		Amount float64 `excel:"金额(元)"`
+		Mobile string  `excel:"mobile|手机号"`
+	}
+	rows := [][]interface{}{
+		{" name\n", "金额（元）", "MOBILE"},
+		{"a", 1.5, "123"},
+	}
+
+	// 默认精确匹配
+	f := openTestFile(t, rows)
+	var customers []Customer4Normalize
+	if assert.NoError(t, f.Decode(&customers)) {
+		assert.Equal(t, []Customer4Normalize{{}}, customers)
+	}
+
+	f = openTestFile(t, rows)
+	f.SetHeaderNormalizeOption(HeaderNormalizeAll)
+	customers = nil
+	if assert.NoError(t, f.Decode(&customers)) {
+		assert.Equal(t, []Customer4Normalize{{Name: "a", Amount: 1.5, Mobile: "123"}}, customers)
+	}
+
+	// 自定义规范化函数在规范化选项之后执行
+	f = openTestFile(t, [][]interface{}{{"客户名称", "手机号"}, {"a", "123"}})
+	f.SetHeaderNormalizeOption(HeaderFoldCase)
+	f.SetHeaderNormalizer(func(header string) string {
+		return strings.TrimPrefix(header, "客户")
+	})
+	type Customer4Normalizer struct {
+		Name   string `excel:"名称"`
+		Mobile string `excel:"Mobile|手机号"`
+	}
+	var others []Customer4Normalizer
+	if assert.NoError(t, f.Decode(&others)) {
+		assert.Equal(t, []Customer4Normalizer{{Name: "a", Mobile: "123"}}, others)
+	}
+
+	// 不同的表头规范化后相同
+	f = openTestFile(t, [][]interface{}{{"Name", "金额(元)", "name "}, {"a", 1.5, "b"}})
+	f.SetHeaderNormalizeOption(HeaderNormalizeAll)
+	customers = nil
+	err := f.Decode(&customers)
+	assert.True(t, errors.Is(err, ErrHeaderNormalizeConflict))
+	assert.Contains(t, err.Error(), `headers: "Name", "name ", normalized: "name"`)
+	assert.Empty(t, customers)
+}
+
+func Test_RequiredAndStrictHeaders(t *testing.T) {
+	type Customer4Required struct {
+		Name   string `excel:"姓名,required"`
+		IDCard string `excel:"身份证|证件号,required"`
+		Mobile string `excel:"手机号,required"`
+		Remark string `excel:"备注"`
+	}
+	rows := [][]interface{}{{"姓名", "地址", "备注", "性别"}, {"a", "b", "c", "d"}}
+
+	// 缺少的必需表头全部列出, 不解码任何一行
+	f := openTestFile(t, rows)
+	count := 0
+	f.OnProgress(func(processed int, total int) {
+		count++
+	})
+	var customers []Customer4Required
+	err := f.Decode(&customers)
+	var missingErr *MissingHeadersError
+	if assert.True(t, errors.As(err, &missingErr)) {
+		assert.Equal(t, defaultSheetName, missingErr.Sheet)
+		assert.Equal(t, []string{"身份证", "手机号"}, missingErr.Headers)
+	}
+	assert.True(t, errors.Is(err, ErrMissingHeaders))
+	assert.Empty(t, customers)
+	assert.Equal(t, 0, count)
+	assert.True(t, errors.Is(f.ValidateHeaders(Customer4Required{}), ErrMissingHeaders))
+
+	// 严格模式下拒绝没有对应字段的表头
+	type Customer4Strict struct {
+		Name   string `excel:"姓名,required"`
+		Remark string `excel:"备注"`
+	}
+	assert.NoError(t, f.ValidateHeaders(&Customer4Strict{}))
+	f.SetStrict(true)
+	err = f.ValidateHeaders(&Customer4Strict{})
+	var unknownErr *UnknownHeadersError
+	if assert.True(t, errors.As(err, &unknownErr)) {
+		assert.Equal(t, []string{"地址", "性别"}, unknownErr.Headers)
+	}
+	var others []Customer4Strict
+	err = f.Decode(&others)
+	assert.True(t, errors.Is(err, ErrUnknownHeaders))
+	assert.Empty(t, others)
+}
+
+func Test_HeaderRowPosition(t *testing.T) {
+	type Customer4HeaderRow struct {
+		Name string `excel:"姓名"`
+		Age  int    `excel:"年龄|Age"`
+	}
+	rows := [][]interface{}{
+		{"2024 年客户名单"},
+		{"导出时间", "2024-01-01"},
+		{"姓名", "年龄", "备注"},
+		{"请填写真实姓名", "整数"},
+		{"a", 1},
+		{"b", "x"},
+	}
+
+	f := openTestFile(t, rows)
+	f.SetHeaderRow(3)
+	f.SetDataStartRow(5)
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+	headers, err := f.GetHeadersFromExcel()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"姓名", "年龄", "备注"}, headers)
+	}
+	var customers []Customer4HeaderRow
+	err = f.Decode(&customers)
+	assert.Equal(t, []Customer4HeaderRow{{Name: "a", Age: 1}}, customers)
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 1) {
+		assert.Equal(t, 6, decodeErrs[0].Row)
+	}
+
+	// 自动检测表头, 第一个数据行默认为表头的下一行
+	f = openTestFile(t, rows)
+	f.SetHeaderDetection(&Customer4HeaderRow{}, 5)
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var customer Customer4HeaderRow
+	if assert.True(t, c.Next()) && assert.Error(t, c.Scan(&customer)) {
+		assert.Equal(t, 4, c.Row())
+	}
+
+	// 扫描范围内没有匹配的行
+	f.SetHeaderDetection(&Customer4HeaderRow{}, 2)
+	_, err = f.Cursor()
+	assert.True(t, errors.Is(err, ErrExcelHeaderNotFound))
+
+	// 表头行超出 sheet 范围
+	f.SetHeaderDetection(nil, 0)
+	f.SetHeaderRow(10)
+	_, err = f.Cursor()
+	assert.True(t, errors.Is(err, ErrExcelHeaderNotFound))
+}
+
+func Test_GroupedHeaders(t *testing.T) {
+	type Contact4Grouped struct {
+		Name   string `excel:"姓名"`
+		Mobile string `excel:"电话"`
+	}
+	type Order4Grouped struct {
+		ID      string          `excel:"订单号"`
+		Contact Contact4Grouped `excel:",prefix=联系人/"`
+		Remark  string          `excel:"备注"`
+	}
+	orders := []Order4Grouped{
+		{ID: "1", Contact: Contact4Grouped{Name: "a", Mobile: "123"}, Remark: "x"},
+		{ID: "2", Contact: Contact4Grouped{Name: "b", Mobile: "456"}},
+	}
+
+	// 开启分组表头后, 根据嵌套结构体的前缀生成分组表头
+	w := NewFile()
+	w.SetGroupedHeaders(true)
+	if !assert.NoError(t, w.Write(orders)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) && assert.Len(t, rows, 4) {
+		assert.Equal(t, []string{"订单号", "联系人", "", "备注"}, rows[0])
+		assert.Equal(t, []string{"", "姓名", "电话", ""}, rows[1])
+	}
+
+	// 读取多行表头
+	f.SetHeaderRowCount(2)
+	headers, err := f.GetHeadersFromExcel()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"订单号", "联系人/姓名", "联系人/电话", "备注"}, headers)
+	}
+	var decoded []Order4Grouped
+	if assert.NoError(t, f.Decode(&decoded)) {
+		assert.Equal(t, orders, decoded)
+	}
+
+	// 路径 tag 直接引用分组下的表头
+	type Mobile4Grouped struct {
+		Mobile string `excel:"联系人/电话"`
+	}
+	var mobiles []Mobile4Grouped
+	if assert.NoError(t, f.Decode(&mobiles)) {
+		assert.Equal(t, []Mobile4Grouped{{Mobile: "123"}, {Mobile: "456"}}, mobiles)
+	}
+
+	// 自动检测多行表头
+	f.SetHeaderDetection(Mobile4Grouped{}, 3)
+	c, err := f.Cursor()
+	if assert.NoError(t, err) && assert.True(t, c.Next()) {
+		assert.Equal(t, 3, c.Row())
+	}
+}
+
+func Test_PathHeaderWithoutGrouping(t *testing.T) {
+	type Product4Path struct {
+		Name  string  `excel:"名称"`
+		Price float64 `excel:"单价/元"`
+	}
+	products := []Product4Path{{Name: "a", Price: 1.5}, {Name: "b", Price: 2}}
+
+	// 默认不拆分包含 "/" 的表头, 写入与解码均使用单行表头
+	w := NewFile()
+	if !assert.NoError(t, w.Write(products)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{{"名称", "单价/元"}, {"a", "1.5"}, {"b", "2"}}, rows)
+	}
+	var decoded []Product4Path
+	if assert.NoError(t, f.Decode(&decoded)) {
+		assert.Equal(t, products, decoded)
+	}
+}
@@ -0,0 +1,33 @@
+package excel
+
+import (
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// openTestFile 以 rows 为内容构造只有一个 sheet 的 excel, 并以读取模式打开
+func openTestFile(t *testing.T, rows [][]interface{}) (f *File) {
+	ef := excelize.NewFile()
+	for i, row := range rows {
+		axis, err := excelize.CoordinatesToCellName(1, i+1)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		row := row
+		if !assert.NoError(t, ef.SetSheetRow(defaultSheetName, axis, &row)) {
+			t.FailNow()
+		}
+	}
+
+	buf, err := ef.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	f, err = OpenReader(buf)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package excel
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CellUnmarshaler 可以从单元格解码自身的类型
+//
+// 参数与 FieldParser 一致, 当字段既没有 tag 解析器, 也没有类型解析器时使用
+type CellUnmarshaler interface {
+	UnmarshalCell(valueStr string, col int, row int) error
+}
+
+// CellMarshaler 可以将自身编码为单元格的类型
+//
+// 参数与 FieldFormatter 一致, 当字段既没有 tag 格式化器, 也没有类型格式化器时使用
+type CellMarshaler interface {
+	MarshalCell(col int, row int) (cell interface{}, err error)
+}
+
+var (
+	cellUnmarshalerType = reflect.TypeOf((*CellUnmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// getUnmarshalerParser 如果 t 的指针实现了 CellUnmarshaler 或 encoding.TextUnmarshaler, 返回基于该接口的内部字段解析器
+//
+// CellUnmarshaler 的优先级高于 encoding.TextUnmarshaler
+func getUnmarshalerParser(t reflect.Type) (parser internalFieldParser, found bool) {
+	ptrType := reflect.PtrTo(t)
+
+	switch {
+	case ptrType.Implements(cellUnmarshalerType):
+		parser = func(valueStr string, col int, row int) (value reflect.Value, err error) {
+			ptr := reflect.New(t)
+			err = ptr.Interface().(CellUnmarshaler).UnmarshalCell(valueStr, col, row)
+			if err != nil {
+				err = errors.WithMessagef(err, "str: %s", valueStr)
+				err = errors.WithStack(err)
+				return
+			}
+			value = ptr.Elem()
+			return
+		}
+		found = true
+	case ptrType.Implements(textUnmarshalerType):
+		parser = func(valueStr string, col int, row int) (value reflect.Value, err error) {
+			ptr := reflect.New(t)
+			err = ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(valueStr))
+			if err != nil {
+				err = errors.WithMessagef(err, "str: %s", valueStr)
+				err = errors.WithStack(err)
+				return
+			}
+			value = ptr.Elem()
+			return
+		}
+		found = true
+	}
+
+	return
+}
+
+// marshalCell 如果 value 实现了 CellMarshaler 或 encoding.TextMarshaler, 使用该接口生成单元格的值
+//
+// value 须为解引用后的值, 以指针为接收者的方法同样会被识别.
+// 内置支持的时间类型不走接口, 以保持其写为 excel 日期的行为
+func marshalCell(value interface{}, col int, row int) (cell interface{}, found bool, err error) {
+	switch value.(type) {
+	case nil, time.Time, time.Duration:
+		return
+	}
+
+	// 构造可寻址的副本, 使以指针为接收者的方法也能被调用
+	ptr := reflect.New(reflect.TypeOf(value))
+	ptr.Elem().Set(reflect.ValueOf(value))
+
+	switch m := ptr.Interface().(type) {
+	case CellMarshaler:
+		found = true
+		cell, err = m.MarshalCell(col, row)
+	case encoding.TextMarshaler:
+		found = true
+		var text []byte
+		text, err = m.MarshalText()
+		cell = string(text)
+	}
+	if err != nil {
+		err = errors.WithStack(err)
+	}
+
+	return
+}
@@ -0,0 +1,117 @@
+package excel
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStatus 通过 encoding.TextMarshaler 与 encoding.TextUnmarshaler 支持 excel
+type testStatus int
+
+func (s testStatus) MarshalText() (text []byte, err error) {
+	switch s {
+	case 1:
+		text = []byte("启用")
+	case 2:
+		text = []byte("停用")
+	default:
+		err = fmt.Errorf("unknown status: %d", s)
+	}
+	return
+}
+
+func (s *testStatus) UnmarshalText(text []byte) (err error) {
+	switch string(text) {
+	case "启用":
+		*s = 1
+	case "停用":
+		*s = 2
+	default:
+		err = fmt.Errorf("unknown status: %s", text)
+	}
+	return
+}
+
+// testMoney 通过 CellMarshaler 与 CellUnmarshaler 支持 excel, 以分为单位
+type testMoney int64
+
+func (m *testMoney) MarshalCell(col int, row int) (cell interface{}, err error) {
+	cell = float64(*m) / 100
+	return
+}
+
+func (m *testMoney) UnmarshalCell(valueStr string, col int, row int) (err error) {
+	yuan, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return
+	}
+	*m = testMoney(yuan*100 + 0.5)
+	return
+}
+
+func Test_Marshaler(t *testing.T) {
+	type Account4Marshaler struct {
+		Name    string      `excel:"name"`
+		Status  testStatus  `excel:"status"`
+		Balance testMoney   `excel:"balance"`
+		Prev    *testStatus `excel:"prev"`
+	}
+	disabled := testStatus(2)
+	as := []Account4Marshaler{
+		{Name: "a", Status: 1, Balance: 1250, Prev: &disabled},
+		{Name: "b", Status: 2, Balance: 99},
+	}
+
+	ef, err := BuildFile(as)
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := ef.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, [][]string{
+		{"name", "status", "balance", "prev"},
+		{"a", "启用", "12.5", "停用"},
+		{"b", "停用", "0.99", ""},
+	}, rows)
+
+	var decoded []Account4Marshaler
+	err = f.Decode(&decoded)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, as, decoded)
+}
+
+func Test_MarshalerError(t *testing.T) {
+	type Account4MarshalerError struct {
+		Status testStatus `excel:"status"`
+	}
+
+	_, err := BuildFile([]Account4MarshalerError{{Status: 3}})
+	if !assert.Error(t, err) {
+		return
+	}
+
+	f := openTestFile(t, [][]interface{}{{"status"}, {"未知"}})
+	var decoded []Account4MarshalerError
+	err = f.Decode(&decoded)
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) {
+		assert.Equal(t, "A2", decodeErrs[0].Col+strconv.Itoa(decodeErrs[0].Row))
+	}
+}
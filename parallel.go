@@ -0,0 +1,212 @@
+package excel
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// windowPerWorker 每个 worker 对应的最大在途行数, 用于限制等待按序返回的解码结果占用的内存
+const windowPerWorker = 4
+
+// rowJob 读取到的一行原始数据
+type rowJob struct {
+	seq     int      // 在本轮读取中的顺序, 从 0 开始
+	rowNow  int      // 数据行的序号, 从 1 开始
+	cols    []string // 该行所有单元格的字符串
+	colsErr error    // 读取该行失败的原因
+}
+
+// rowResult 一行数据的解码结果
+type rowResult struct {
+	rowJob
+	elemPtr reflect.Value // 解码得到的元素的指针
+	rowErrs DecodeErrors  // 该行所有的单元格错误
+}
+
+// decodeParallel 并行解码, 参数含义与 decodeEach 相同
+//
+// 为了不多读取 limit 之外的行, 每轮最多读取剩余数量的行, 有行被 errorPolicy 丢弃时再读取下一轮
+func (c *Cursor) decodeParallel(
+	ctx context.Context,
+	elemType reflect.Type,
+	fields []fieldDecoder,
+	limit int,
+	decodeErrs *DecodeErrors,
+	fn func(row int, elemPtr reflect.Value) error,
+) (
+	err error,
+) {
+	for count := 0; count < limit; {
+		var kept int
+		var eof bool
+		kept, eof, err = c.decodeRound(ctx, elemType, fields, limit-count, decodeErrs, fn)
+		count += kept
+		if err != nil || eof {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeRound 最多读取 n 行并行解码, 按行的顺序回调 fn
+//
+// 单个 goroutine 按顺序读取原始行, c.parallelism 个 worker 执行字段解析, 当前 goroutine 按序重组结果.
+// kept 为回调 fn 的次数, eof 表示数据已经读完. 出错时提前读取但未处理的行会被放回 c.pending,
+// 使迭代器停留在出错的行, 与逐行解码一致
+func (c *Cursor) decodeRound(
+	ctx context.Context,
+	elemType reflect.Type,
+	fields []fieldDecoder,
+	n int,
+	decodeErrs *DecodeErrors,
+	fn func(row int, elemPtr reflect.Value) error,
+) (
+	kept int,
+	eof bool,
+	err error,
+) {
+	workers := c.parallelism
+	window := make(chan struct{}, workers*windowPerWorker) // 在途行数的配额, 按序处理完一行后归还
+	stop := make(chan struct{})                            // 出错时通知读取者停止读取
+	jobs := make(chan rowJob)
+	results := make(chan rowResult)
+	// 最后处理的行, 本轮没有处理任何行时为迭代器原本的当前行, 须在读取者启动之前获取
+	last := rowJob{rowNow: c.rowNow, cols: c.cols}
+
+	// 读取者, 迭代器的状态只在此 goroutine 中被修改
+	var readErr error
+	var unsent *rowJob // 已读取但没有交给 worker 的行
+	go func() {
+		defer close(jobs)
+		for seq := 0; seq < n; seq++ {
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return
+			}
+			if !c.Next() {
+				eof = true
+				return
+			}
+			job := rowJob{seq: seq, rowNow: c.rowNow}
+			job.cols, job.colsErr = c.columns()
+			if job.colsErr != nil {
+				readErr = job.colsErr
+				unsent = &job
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-stop:
+				unsent = &job
+				return
+			}
+		}
+	}()
+
+	// worker
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				elemPtr := reflect.New(elemType)
+				rowErrs := c.buildOneElem(job.cols, job.rowNow, fields, elemPtr)
+				results <- rowResult{rowJob: job, elemPtr: elemPtr, rowErrs: rowErrs}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 按行的顺序处理结果, 出错后继续读空 results 以便所有 goroutine 退出
+	pending := make(map[int]rowResult)
+	next := 0
+	for result := range results {
+		pending[result.seq] = result
+		if err != nil {
+			continue
+		}
+
+		for err == nil {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			// ctx 已取消时该行尚未处理, 放回 pending
+			err = contextErr(ctx)
+			if err != nil {
+				pending[r.seq] = r
+				close(stop)
+				break
+			}
+
+			last = r.rowJob
+			var keep bool
+			keep, err = c.handleResult(r, decodeErrs, fn)
+			if err != nil {
+				close(stop)
+				break
+			}
+			if keep {
+				kept++
+			}
+		}
+	}
+
+	// results 关闭时读取者已经退出, 可以安全读取其状态
+	if err == nil {
+		err = readErr
+		return
+	}
+	c.unread(last, pending, unsent)
+
+	return
+}
+
+// unread 将提前读取但未处理的行按顺序放回 c.pending, 并将迭代器的当前行恢复为最后处理的行 last
+func (c *Cursor) unread(last rowJob, pending map[int]rowResult, unsent *rowJob) {
+	jobs := make([]rowJob, 0, len(pending)+1)
+	for _, r := range pending {
+		jobs = append(jobs, r.rowJob)
+	}
+	if unsent != nil {
+		jobs = append(jobs, *unsent)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].seq < jobs[j].seq
+	})
+
+	c.pending = append(jobs, c.pending...)
+	c.rowNow, c.cols, c.colsErr = last.rowNow, last.cols, nil
+}
+
+// handleResult 按照 errorPolicy 处理一行的解码结果, 需要保留时回调 fn, keep 为 false 表示该行被丢弃
+func (c *Cursor) handleResult(
+	r rowResult,
+	decodeErrs *DecodeErrors,
+	fn func(row int, elemPtr reflect.Value) error,
+) (
+	keep bool,
+	err error,
+) {
+	c.onProgress(r.rowNow)
+
+	keep, err = c.applyErrorPolicy(r.rowErrs, decodeErrs)
+	if err != nil || !keep {
+		return
+	}
+
+	err = fn(r.rowNow+c.rowOffset, r.elemPtr)
+	return
+}
@@ -0,0 +1,179 @@
+package excel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer4Parallel struct {
+	Name string `excel:"name"`
+	Age  int    `excel:"age"`
+}
+
+// openParallelTestFile 构造 n 行数据, 每第 10 行的 age 无法解析, name 使用耗时不一的 tag 解析器
+func openParallelTestFile(t *testing.T, n int) (f *File) {
+	rows := [][]interface{}{{"name", "age"}}
+	for i := 1; i <= n; i++ {
+		var age interface{} = i
+		if i%10 == 0 {
+			age = "x"
+		}
+		rows = append(rows, []interface{}{strings.Repeat("a", i%7+1), age})
+	}
+	f = openTestFile(t, rows)
+	f.RegisterTagParser("name", func(valueStr string, col int, row int) (value interface{}, err error) {
+		// 让后读取的行有机会先完成解析
+		time.Sleep(time.Duration(len(valueStr)) * 100 * time.Microsecond)
+		value = strings.ToUpper(valueStr)
+		return
+	})
+	return
+}
+
+func Test_DecodeParallel(t *testing.T) {
+	f := openParallelTestFile(t, 100)
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var want []Customer4Parallel
+	wantErr := f.Decode(&want)
+
+	var processed []int
+	f.OnProgress(func(p int, total int) {
+		processed = append(processed, p)
+	})
+	f.SetParallelism(4)
+	var got []Customer4Parallel
+	err := f.Decode(&got)
+
+	assert.Len(t, got, 90)
+	assert.Equal(t, want, got)
+	assert.Equal(t, wantErr.Error(), err.Error())
+	var decodeErrs DecodeErrors
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 10) {
+		for i, de := range decodeErrs {
+			assert.Equal(t, (i+1)*10+1, de.Row) // 第 10n 个数据行在 excel 中为第 10n+1 行
+			assert.Equal(t, "B", de.Col)
+		}
+	}
+	if assert.Len(t, processed, 100) {
+		for i, p := range processed {
+			assert.Equal(t, i+1, p)
+		}
+	}
+
+	// 遇到错误立即停止
+	f.SetErrorPolicy(ErrorPolicyFailFast)
+	got = nil
+	err = f.Decode(&got)
+	if assert.True(t, errors.As(err, &decodeErrs)) && assert.Len(t, decodeErrs, 1) {
+		assert.Equal(t, 11, decodeErrs[0].Row)
+	}
+	assert.Len(t, got, 9)
+}
+
+func Test_DecodeManyParallel(t *testing.T) {
+	f := openParallelTestFile(t, 25)
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+	f.SetParallelism(3)
+	c, err := f.Cursor()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 被丢弃的行不计入 limit, 且不会多读取 limit 之外的行
+	var customers []Customer4Parallel
+	var ages []int
+	for {
+		count, err := c.DecodeMany(&customers, 6)
+		var decodeErrs DecodeErrors
+		if err != nil && !assert.True(t, errors.As(err, &decodeErrs)) {
+			return
+		}
+		for _, customer := range customers {
+			ages = append(ages, customer.Age)
+		}
+		if count < 6 {
+			break
+		}
+	}
+	assert.Len(t, ages, 23)
+	for i, age := range ages {
+		assert.Equal(t, i+1+i/9, age)
+	}
+
+	// 逐行回调的行号与顺序
+	var rows []int
+	err = f.Each(&Customer4Parallel{}, func(row int, elemPtr interface{}) error {
+		customer := elemPtr.(*Customer4Parallel)
+		assert.Equal(t, row-1, customer.Age)
+		rows = append(rows, row)
+		if len(rows) == 12 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 13, 14}, rows)
+}
+
+func Test_DecodeParallelResume(t *testing.T) {
+	// 逐行解码与并行解码出错后, 迭代器停留在相同的位置
+	decode := func(parallelism int) (ages [][]int, rows []int) {
+		f := openParallelTestFile(t, 25)
+		f.SetParallelism(parallelism)
+		c, err := f.Cursor()
+		if !assert.NoError(t, err) {
+			return
+		}
+		record := func(customers []Customer4Parallel) {
+			batch := make([]int, 0, len(customers))
+			for _, customer := range customers {
+				batch = append(batch, customer.Age)
+			}
+			ages = append(ages, batch)
+			rows = append(rows, c.Row())
+		}
+
+		for i := 0; i < 4; i++ {
+			var customers []Customer4Parallel
+			_, err = c.DecodeMany(&customers, 8)
+			record(customers)
+		}
+
+		// 回调返回错误
+		var customers []Customer4Parallel
+		err = c.Each(&Customer4Parallel{}, func(row int, elemPtr interface{}) error {
+			customers = append(customers, *elemPtr.(*Customer4Parallel))
+			return errors.New("stop")
+		})
+		assert.EqualError(t, err, "stop")
+		record(customers)
+
+		// ctx 已取消
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		customers = nil
+		_, err = c.DecodeManyContext(ctx, &customers, 5)
+		assert.True(t, errors.Is(err, context.Canceled))
+		record(customers)
+
+		customers = nil
+		_, err = c.DecodeMany(&customers, 100)
+		assert.NoError(t, err)
+		record(customers)
+		return
+	}
+
+	wantAges, wantRows := decode(0)
+	gotAges, gotRows := decode(4)
+	assert.Equal(t, wantAges, gotAges)
+	assert.Equal(t, wantRows, gotRows)
+	assert.Equal(t, []int{9, 11, 19, 21, 22, 22, 26}, wantRows)
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 16, 17, 18}, wantAges[2]) // 第 10 个数据行出错后, 下一次解码从第 11 个数据行开始
+	assert.Equal(t, []int{22, 23, 24, 25}, wantAges[6])                 // ctx 取消时没有丢失任何行
+}
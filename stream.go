@@ -1,27 +1,85 @@
 package excel
 
 import (
+	"context"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/360EntSecGroup-Skylar/excelize/v2"
 	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure/tag"
 )
 
+// defaultTimeNumFmt time.Time 默认的单元格数字格式
+const defaultTimeNumFmt = "yyyy-mm-dd hh:mm:ss"
+
 // Stream 流式写入工具
 type Stream struct {
-	headersSet     [][]string // 被外部设置的表头
-	headerTags     []string   // 从结构体 tag 读取到的表头
-	headersWritten bool       // 表头已写入文件
-	sw             *excelize.StreamWriter
-	rowNow         int // 目前写到的行数
+	headersSet      [][]string  // 被外部设置的表头
+	headerTags      []string    // 从结构体 tag 读取到的表头
+	schema          *tag.Schema // 被写入元素的类型对应的 Schema
+	headersWritten  bool        // 表头已写入文件
+	ef              *excelize.File
+	sw              *excelize.StreamWriter
+	sheetName       string                          // 写入的 sheet 名
+	rowNow          int                             // 目前写到的行数
+	numFmtStyles    map[string]int                  // 数字格式与样式 ID 的映射, 避免重复创建样式
+	typeFormatters  map[reflect.Type]FieldFormatter // 类型格式化器, 其优先级低于 tagFormatters
+	tagFormatters   map[string]FieldFormatter       // tag 格式化器, 其优先级高于 typeFormatters
+	enums           map[string]*enum                // 已注册的枚举, 其优先级介于 tagFormatters 与 typeFormatters 之间
+	written         int                             // 已写入的数据行数
+	validationRows  int                             // 数据有效性规则覆盖的行数, 不大于 0 时不生成规则
+	groupedHeaders  bool                            // 是否将路径形式的表头拆分为多行分组表头
+	dropListRefs    map[string]string               // 写入隐藏 sheet 的下拉列表与其引用区域的映射
+	progressHandler ProgressHandler                 // 每写入一行数据都会触发此回调
+}
+
+// OnProgress 设置进度回调, 每写入一行数据都会触发
+//
+// processed 为该写入器已写入的数据行数, total 为目前已知的总行数, 即之前写入的行数加上本次 WriteMany 的元素数量
+func (s *Stream) OnProgress(h ProgressHandler) {
+	s.progressHandler = h
+}
+
+func (s *Stream) onProgress(total int) {
+	if s.progressHandler == nil {
+		return
+	}
+	s.progressHandler(s.written, total)
+}
+
+// SetGroupedHeaders 设置写入时是否将以 "/" 分隔的路径形式的表头拆分为多行分组表头, 默认为 false
+//
+// 开启后如 "联系人/姓名"、"联系人/电话" 生成两行表头, "联系人" 横向合并两列; 关闭时表头原样写入一行.
+// 必须在写入表头之前设置, 外部设定的表头不受影响
+func (s *Stream) SetGroupedHeaders(grouped bool) {
+	s.groupedHeaders = grouped
+}
+
+// RegisterTypeFormatter 注册类型格式化器
+func (s *Stream) RegisterTypeFormatter(elem interface{}, formatter FieldFormatter) {
+	t := reflect.TypeOf(elem)
+	s.typeFormatters[t] = formatter
+}
+
+// RegisterTagFormatter 注册字段格式化器
+func (s *Stream) RegisterTagFormatter(excelTag string, formatter FieldFormatter) {
+	s.tagFormatters[excelTag] = formatter
 }
 
 // WriteMany 批量写入多个元素
 //
 // elems 必须是数组或切片
 func (s *Stream) WriteMany(elems interface{}) (err error) {
+	return s.WriteManyContext(context.Background(), elems)
+}
+
+// WriteManyContext 与 WriteMany 相同, 每行写入前检查 ctx 是否已取消, 已取消时返回 ctx.Err()
+//
+// 取消前已写入的数据依然保留在流式写入器中
+func (s *Stream) WriteManyContext(ctx context.Context, elems interface{}) (err error) {
 	t := reflect.TypeOf(elems)
 	kind := t.Kind()
 	if kind != reflect.Array && kind != reflect.Slice {
@@ -33,48 +91,95 @@ func (s *Stream) WriteMany(elems interface{}) (err error) {
 	// 遍历数组所有元素, 流式写入 excel
 	elemsValue := reflect.ValueOf(elems)
 	lenOfElems := elemsValue.Len()
+	if lenOfElems == 0 && t.Elem().Kind() != reflect.Interface {
+		// 没有元素时从元素类型生成表头, 使导出结果依然是可识别的文件
+		err = s.writeHeader(t.Elem())
+		return
+	}
+	total := s.written + lenOfElems
 	for i := 0; i < lenOfElems; i++ {
-		elem := elemsValue.Index(i).Interface()
+		err = contextErr(ctx)
+		if err != nil {
+			break
+		}
 
-		// 从结构体 tag 初始化表头
-		if !s.headersWritten {
-			err = s.initHeaderTags(elem)
-			if err != nil {
-				break
-			}
+		elem := elemsValue.Index(i).Interface()
 
-			// 将表头写入文件
-			err = s.writeHeaders2Excel()
-			if err != nil {
-				break
-			}
+		// 从结构体 tag 初始化表头, 并将表头写入文件
+		err = s.writeHeader(reflect.TypeOf(elem))
+		if err != nil {
+			break
 		}
 
 		// 生成本 row 的数据
-		row := buildRow(elem, s.headerTags)
+		var row []interface{}
+		row, err = s.buildRow(elem)
+		if err != nil {
+			break
+		}
 
 		// 将 row 写入 excel
-		axis := "A" + strconv.Itoa(s.rowNow+1) // 坐标从 0 开始, excel row 从 A1 开始
-		err = s.sw.SetRow(axis, row)
+		err = s.writeRow(row)
 		if err != nil {
-			err = errors.WithStack(err)
 			break
 		}
-		s.rowNow++
+		s.written++
+		s.onProgress(total)
 	}
 
 	return
 }
 
-func (s *Stream) initHeaderTags(elem interface{}) (err error) {
+// WriteHeader 根据元素类型写入表头, elem 为元素或其指针, 只用于获取类型. 表头已写入时不做任何操作
+//
+// 用于在没有任何数据时也生成只有表头的文件
+func (s *Stream) WriteHeader(elem interface{}) (err error) {
+	err = s.writeHeader(reflect.TypeOf(elem))
+	return
+}
+
+// writeHeader 从元素类型 t 的结构体 tag 初始化表头, 并将表头写入文件
+func (s *Stream) writeHeader(t reflect.Type) (err error) {
+	if s.headersWritten {
+		return
+	}
+
+	err = s.initHeaderTags(t)
+	if err != nil {
+		return
+	}
+	err = s.writeHeaders2Excel()
+	return
+}
+
+// writeRow 将 row 写入下一行
+func (s *Stream) writeRow(row []interface{}) (err error) {
+	axis := "A" + strconv.Itoa(s.rowNow+1) // 坐标从 0 开始, excel row 从 A1 开始
+	err = s.sw.SetRow(axis, row)
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	s.rowNow++
+	return
+}
+
+// initHeaderTags 从元素类型 t 的结构体 tag 初始化表头
+func (s *Stream) initHeaderTags(t reflect.Type) (err error) {
 	if len(s.headerTags) > 0 {
 		return
 	}
 
-	s.headerTags = getTags(elem)
+	s.schema = getSchema(t)
+	for _, field := range s.schema.Fields {
+		err = checkTagOptions(field)
+		if err != nil {
+			return
+		}
+	}
+	s.headerTags = s.schema.Names()
 	if len(s.headerTags) == 0 {
 		// 没找到表头, 该元素不可用
-		t := reflect.TypeOf(elem)
 		err = errors.WithMessagef(
 			ErrTagNotFound,
 			"type: %s(%s)",
@@ -113,22 +218,110 @@ func (s *Stream) writeHeaders2Excel() (err error) {
 		}
 
 		s.headersWritten = true
+		err = s.addDataValidations()
 		return
 	}
 
-	// 当不存在外部设定的表头，使用自动生成的表头
-	headers := strSlice2interfaceSlice(s.headerTags)
-	err = s.sw.SetRow("A1", headers)
-	if err != nil {
-		err = errors.WithStack(err)
-		return
+	// 当不存在外部设定的表头，使用自动生成的表头, 开启分组表头时路径形式的表头生成多行分组表头
+	rows := [][]interface{}{strSlice2interfaceSlice(s.headerTags)}
+	var merges [][2]string
+	if s.groupedHeaders {
+		rows, merges = groupHeaders(s.headerTags)
+	}
+	for i, row := range rows {
+		axis := "A" + strconv.Itoa(i+1)
+		err = s.sw.SetRow(axis, row)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
 	}
-	s.rowNow = 1
+	for _, merge := range merges {
+		err = s.ef.MergeCell(s.sheetName, merge[0], merge[1])
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+	s.rowNow = len(rows)
 	s.headersWritten = true
+	err = s.addDataValidations()
+
+	return
+}
+
+// groupHeaders 将路径形式的表头拆分为多行分组表头, merges 为需要合并的单元格区域的左上角与右下角
+//
+// 如 ["订单号", "联系人/姓名", "联系人/电话"] 生成两行表头, "联系人" 横向合并两列, "订单号" 纵向合并两行.
+// 不包含路径的表头只生成一行
+func groupHeaders(headers []string) (rows [][]interface{}, merges [][2]string) {
+	paths := make([][]string, 0, len(headers))
+	depth := 1
+	for _, header := range headers {
+		path := strings.Split(header, headerPathSeparator)
+		if len(path) > depth {
+			depth = len(path)
+		}
+		paths = append(paths, path)
+	}
 
+	rows = make([][]interface{}, depth)
+	for level := range rows {
+		rows[level] = make([]interface{}, len(paths))
+		for col := 0; col < len(paths); {
+			path := paths[col]
+			if level >= len(path) {
+				// 已被上方的单元格纵向合并
+				rows[level][col] = nil
+				col++
+				continue
+			}
+			rows[level][col] = path[level]
+
+			// 叶子表头纵向合并到最后一行
+			if level == len(path)-1 {
+				if level < depth-1 {
+					merges = append(merges, [2]string{cellName(col, level), cellName(col, depth-1)})
+				}
+				col++
+				continue
+			}
+
+			// 相同分组的相邻列横向合并
+			end := col + 1
+			for end < len(paths) && len(paths[end]) > level+1 && equalStrings(paths[end][:level+1], path[:level+1]) {
+				rows[level][end] = nil
+				end++
+			}
+			if end-col > 1 {
+				merges = append(merges, [2]string{cellName(col, level), cellName(end-1, level)})
+			}
+			col = end
+		}
+	}
+
+	return
+}
+
+// cellName 获取坐标对应的单元格名, col 与 row 均从 0 开始
+func cellName(col int, row int) (name string) {
+	name, _ = excelize.CoordinatesToCellName(col+1, row+1)
 	return
 }
 
+// equalStrings 判断两个字符串切片是否相同
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // Close 关闭流式写入器
 //
 // 此方法会将缓冲区的数据强制刷到 excel,
@@ -143,19 +336,170 @@ func (s *Stream) Close() (err error) {
 	return
 }
 
-func buildRow(item interface{}, headerTags []string) (row []interface{}) {
-	row = make([]interface{}, 0, len(headerTags))
+func (s *Stream) buildRow(item interface{}) (row []interface{}, err error) {
+	row = make([]interface{}, 0, len(s.headerTags))
 
-	header2Value := getHeader2Value(item)
+	header2Value := s.schema.Values(reflect.ValueOf(item))
 
-	for _, header := range headerTags {
+	for col, field := range s.schema.Fields {
+		header := field.Name
 		value := header2Value[header]
+
+		// 优先使用 tag 格式化器, 其次为枚举与类型格式化器, 最后为类型自身实现的编码接口
+		var formatter FieldFormatter
+		var found bool
+		formatter, found, err = s.getFieldFormatter(header, field.Field.Type, field.Options)
+		if err != nil {
+			return
+		}
+		value = derefValue(value)
+		if found && value != nil {
+			value, err = formatter(value, col, s.rowNow)
+		} else {
+			var marshaled interface{}
+			marshaled, found, err = marshalCell(value, col, s.rowNow)
+			if found {
+				value = marshaled
+			}
+		}
+		if err != nil {
+			err = errors.WithMessagef(err, "header: %s, row: %d", header, s.rowNow+1)
+			err = errors.WithStack(err)
+			return
+		}
+
+		// 时间类型写为带数字格式的单元格
+		value, err = s.styleTimeValue(value, field.Options)
+		if err != nil {
+			return
+		}
+
 		row = append(row, value)
 	}
 
 	return
 }
 
+// getFieldFormatter 获取字段格式化器, 优先使用 tag 格式化器, 如果 tag 格式化器不存在, 则使用类型格式化器
+//
+// tag 中指定了 enum 选项时, 使用该枚举将值映射为显示文本, 其优先级介于两者之间.
+// 指针类型没有注册格式化器时, 使用其指向类型的格式化器
+func (s *Stream) getFieldFormatter(excelTag string, t reflect.Type, opts tag.Options) (formatter FieldFormatter, found bool, err error) {
+	formatter, found = s.tagFormatters[excelTag]
+	if found {
+		return
+	}
+
+	e, found, err := getEnum(s.enums, opts)
+	if err != nil {
+		err = errors.WithMessagef(err, "header: %s", excelTag)
+		return
+	}
+	if found {
+		formatter = func(value interface{}, col int, row int) (cell interface{}, err error) {
+			return e.label(value)
+		}
+		return
+	}
+
+	for t != nil {
+		formatter, found = s.typeFormatters[t]
+		if found || t.Kind() != reflect.Ptr {
+			return
+		}
+		t = t.Elem()
+	}
+
+	return
+}
+
+// styleTimeValue 将 time.Time 包装为带数字格式的单元格, time.Duration 写为文本, 其他类型原样返回
+//
+// tag 中指定了 layout 选项时, 使用与之对应的数字格式
+func (s *Stream) styleTimeValue(value interface{}, opts tag.Options) (cell interface{}, err error) {
+	var numFmt string
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			// 零值时间写为空单元格
+			return
+		}
+		// excel 不包含时区信息, 保留墙上时间
+		value = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+		numFmt = defaultTimeNumFmt
+	case time.Duration:
+		// excelize 读取 [h]:mm:ss 格式时会丢失小时数, 时长以 Go 格式的文本写入
+		cell = v.String()
+		return
+	default:
+		cell = value
+		return
+	}
+	if layout, ok := opts.Get("layout"); ok {
+		numFmt = layout2NumFmt(layout)
+	}
+
+	styleID, err := s.getNumFmtStyle(numFmt)
+	if err != nil {
+		return
+	}
+	cell = excelize.Cell{StyleID: styleID, Value: value}
+	return
+}
+
+// getNumFmtStyle 获取指定数字格式的样式 ID, 不存在时新建
+func (s *Stream) getNumFmtStyle(numFmt string) (styleID int, err error) {
+	styleID, ok := s.numFmtStyles[numFmt]
+	if ok {
+		return
+	}
+
+	styleID, err = s.ef.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+	if err != nil {
+		err = errors.WithMessagef(err, "number format: %s", numFmt)
+		err = errors.WithStack(err)
+		return
+	}
+	s.numFmtStyles[numFmt] = styleID
+	return
+}
+
+// layout2NumFmt 将 Go 的时间 layout 转换为 excel 的数字格式
+func layout2NumFmt(layout string) (numFmt string) {
+	r := strings.NewReplacer(
+		"2006", "yyyy",
+		"06", "yy",
+		"01", "mm",
+		"02", "dd",
+		"15", "hh",
+		"04", "mm",
+		"05", "ss",
+		"1", "m",
+		"2", "d",
+		"3", "h",
+		"4", "m",
+		"5", "s",
+	)
+	numFmt = r.Replace(layout)
+	return
+}
+
+// derefValue 对指针解引用, nil 指针对应空单元格
+func derefValue(value interface{}) (dst interface{}) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	dst = v.Interface()
+	return
+}
+
 // 去除空格
 func trimSpaceStrSlice(src []string) (dst []string) {
 	dst = make([]string, 0, len(src))
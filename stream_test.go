@@ -1,7 +1,9 @@
 package excel
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"testing"
 
@@ -160,3 +162,203 @@ func Test_trimSpaceStrSlice(t *testing.T) {
 	}
 	assert.Equal(t, expected, dst)
 }
+
+func Test_WritePointer(t *testing.T) {
+	type Supplier4WritePointer struct {
+		Name  string `excel:"name"`
+		Stock *int   `excel:"stock"`
+	}
+	stock := 0
+
+	ef, err := BuildFile([]Supplier4WritePointer{
+		{Name: "a", Stock: &stock},
+		{Name: "b"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := ef.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, [][]string{{"name", "stock"}, {"a", "0"}, {"b", ""}}, rows)
+
+	var suppliers []Supplier4WritePointer
+	err = f.Decode(&suppliers)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, suppliers, 2) {
+		assert.Equal(t, &stock, suppliers[0].Stock)
+		assert.Nil(t, suppliers[1].Stock)
+	}
+}
+
+func Test_FieldFormatter(t *testing.T) {
+	type Level4Formatter int
+	type Order4Formatter struct {
+		ID    string           `excel:"id"`
+		Level Level4Formatter  `excel:"level"`
+		Price int64            `excel:"price"`
+		Prev  *Level4Formatter `excel:"prev"`
+	}
+	levels := map[Level4Formatter]string{1: "低", 2: "高"}
+	high := Level4Formatter(2)
+	os := []Order4Formatter{
+		{ID: "a", Level: 1, Price: 1250, Prev: &high},
+		{ID: "b", Level: 2, Price: 99},
+	}
+
+	f := NewFile()
+	f.RegisterTypeFormatter(Level4Formatter(0), func(value interface{}, col int, row int) (cell interface{}, err error) {
+		cell = levels[value.(Level4Formatter)]
+		return
+	})
+	f.RegisterTagFormatter("price", func(value interface{}, col int, row int) (cell interface{}, err error) {
+		cell = fmt.Sprintf("%.2f", float64(value.(int64))/100)
+		return
+	})
+	err := f.Write(os)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	rf, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := rf.Export().GetRows(defaultSheetName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, [][]string{
+		{"id", "level", "price", "prev"},
+		{"a", "低", "12.50", "高"},
+		{"b", "高", "0.99", ""},
+	}, rows)
+
+	// 格式化器报错
+	s, err := NewFile().Stream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.RegisterTagFormatter("id", func(value interface{}, col int, row int) (cell interface{}, err error) {
+		err = errors.New("bad id")
+		return
+	})
+	err = s.WriteMany(os)
+	assert.Error(t, err)
+}
+
+func Test_WriteManyContextAndProgress(t *testing.T) {
+	type Customer4Context struct {
+		Name string `excel:"name"`
+	}
+	cs := []Customer4Context{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	f := NewFile()
+	var processed, totals []int
+	f.OnProgress(func(p int, total int) {
+		processed = append(processed, p)
+		totals = append(totals, total)
+	})
+	s, err := f.Stream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = s.WriteManyContext(context.Background(), cs[:2])
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = s.WriteManyContext(context.Background(), cs[2:])
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []int{1, 2, 3}, processed)
+	assert.Equal(t, []int{2, 2, 3}, totals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.WriteManyContext(ctx, cs)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 4, s.rowNow)
+}
+
+func Test_WriteEmpty(t *testing.T) {
+	type Customer4Empty struct {
+		Name string `excel:"name"`
+		Age  int    `excel:"age"`
+	}
+	readRows := func(f *File) (rows [][]string) {
+		buf, err := f.ExportBuffer()
+		if !assert.NoError(t, err) {
+			return
+		}
+		r, err := OpenReader(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+		rows, err = r.Export().GetRows(defaultSheetName)
+		assert.NoError(t, err)
+		return
+	}
+
+	// 空切片从元素类型生成表头
+	for _, elems := range []interface{}{[]Customer4Empty{}, []*Customer4Empty{}, [0]Customer4Empty{}} {
+		f := NewFile()
+		if assert.NoError(t, f.Write(elems)) {
+			assert.Equal(t, [][]string{{"name", "age"}}, readRows(f))
+		}
+	}
+
+	// 元素类型无法得到表头
+	f := NewFile()
+	assert.NoError(t, f.Write([]interface{}{}))
+	assert.Empty(t, readRows(f))
+	assert.True(t, errors.Is(NewFile().Write([]struct{ Name string }{}), ErrTagNotFound))
+
+	// 先写入表头, 再写入数据
+	f = NewFile()
+	s, err := f.Stream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, s.WriteHeader(&Customer4Empty{}))
+	assert.NoError(t, s.WriteHeader(Customer4Empty{}))
+	assert.NoError(t, s.WriteMany([]Customer4Empty{}))
+	assert.NoError(t, s.WriteMany([]Customer4Empty{{Name: "a", Age: 1}}))
+	if assert.NoError(t, s.Close()) {
+		assert.Equal(t, [][]string{{"name", "age"}, {"a", "1"}}, readRows(f))
+	}
+}
+
+func Test_groupHeaders(t *testing.T) {
+	rows, merges := groupHeaders([]string{"订单号", "联系人/姓名", "联系人/电话", "收货/地址/城市", "收货/地址/街道", "收货/邮编"})
+	assert.Equal(t, [][]interface{}{
+		{"订单号", "联系人", nil, "收货", nil, nil},
+		{nil, "姓名", "电话", "地址", nil, "邮编"},
+		{nil, nil, nil, "城市", "街道", nil},
+	}, rows)
+	assert.Equal(t, [][2]string{
+		{"A1", "A3"}, {"B1", "C1"}, {"D1", "F1"},
+		{"B2", "B3"}, {"C2", "C3"}, {"D2", "E2"}, {"F2", "F3"},
+	}, merges)
+
+	// 不包含路径的表头只生成一行
+	rows, merges = groupHeaders([]string{"a", "b"})
+	assert.Equal(t, [][]interface{}{{"a", "b"}}, rows)
+	assert.Empty(t, merges)
+}
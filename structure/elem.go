@@ -33,3 +33,20 @@ func TypeTry2Elem(t reflect.Type) (r reflect.Type) {
 //解引用后得到 []int，TypeNeed2Elem 检测到 []int 是 reflect.Slice 类型，也需要解引用。
 //解引用后得到 int，TypeNeed2Elem 检测到 int 不是需要解引用的类型。
 //最终返回 int 作为结果。
+
+// FieldByIndexAlloc 与 reflect.Value.FieldByIndex 相同, 但路径上的 nil 结构体指针会被自动分配
+//
+// v 必须是可寻址的结构体
+func FieldByIndexAlloc(v reflect.Value, index []int) (field reflect.Value) {
+	field = v
+	for i, x := range index {
+		if i > 0 && field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		field = field.Field(x)
+	}
+	return
+}
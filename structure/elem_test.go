@@ -33,3 +33,22 @@ func Test_TypeTry2Elem(t *testing.T) {
 	typ := TypeTry2Elem(reflect.TypeOf(s))
 	assert.Equalf(t, expected, typ, "type: %s(%s)", typ.String(), typ.Kind().String())
 }
+
+func Test_FieldByIndexAlloc(t *testing.T) {
+	type Inner struct {
+		A int
+	}
+	type Outer struct {
+		B     string
+		Inner *Inner
+	}
+
+	o := Outer{}
+	field := FieldByIndexAlloc(reflect.ValueOf(&o).Elem(), []int{1, 0})
+	field.SetInt(1)
+
+	if !assert.NotNil(t, o.Inner) {
+		return
+	}
+	assert.Equal(t, 1, o.Inner.A)
+}
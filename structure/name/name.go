@@ -1,3 +1,6 @@
+// Package name 获取类型的完整名字
+//
+// Deprecated: 库内部已改为以 reflect.Type 作为缓存的 key, 此包不再被使用, 仅为兼容保留
 package name
 
 import (
@@ -7,6 +10,8 @@ import (
 )
 
 // GetFullTypeName 获取完整类型名, 包含包路径与结构体名
+//
+// Deprecated: 不同作用域内声明的同名结构体会得到相同的名字, 请直接使用 reflect.Type 区分类型
 func GetFullTypeName(item interface{}) (name string) {
 	t := reflect.TypeOf(item)
 
@@ -1,56 +1,24 @@
 package tag
 
-import (
-	"reflect"
-	"sync"
-
-	"github.com/yueja/go-excel-orm/structure"
-	"github.com/yueja/go-excel-orm/structure/name"
-)
-
-var tagIndexCache sync.Map
+import "reflect"
 
 // GetTagIndex 遍历所有字段, 获取指定 tagName 的所有 tag->位置 的映射
+//
+// Deprecated: 位置只能表示顶层结构体的直接字段, 展开的嵌套结构体中的字段不会被返回, 请使用 GetTagIndexPath
 func GetTagIndex(item interface{}, tagName string) (tagIndex map[string]int) {
 	tagIndex = make(map[string]int)
-	if tagName == "" {
-		return
-	}
-
-	name := name.GetFullTypeName(item)
-	key := tagName + "_" + name
-
-	// 从缓存拿 tags
-	tag2IndexI, ok := tagIndexCache.Load(key)
-	if ok {
-		tagIndex = tag2IndexI.(map[string]int)
-		return
+	for name, path := range GetTagIndexPath(item, tagName) {
+		if len(path) == 1 {
+			tagIndex[name] = path[0]
+		}
 	}
-
-	// 缓存不命中，新生成 tags
-	tagIndex = getTagIndex(item, tagName)
-	tagIndexCache.Store(key, tagIndex)
 	return
 }
 
-func getTagIndex(item interface{}, tagName string) (tag2Index map[string]int) {
-	tag2Index = make(map[string]int)
-
-	itemType := reflect.TypeOf(item)
-	itemType = structure.TypeTry2Elem(itemType)
-
-	for i := 0; i < itemType.NumField(); i++ {
-		fieldType := itemType.Field(i)
-
-		tag := fieldType.Tag.Get(tagName)
-
-		if tag == "" {
-			// tag 无值则无须导出
-			continue
-		}
-
-		tag2Index[tag] = i
-	}
-
+// GetTagIndexPath 遍历所有字段, 获取指定 tagName 的所有 tag->位置路径 的映射
+//
+// 位置路径可用于 reflect.Value.FieldByIndex, 嵌套结构体的字段路径长度大于 1. 返回值不可修改
+func GetTagIndexPath(item interface{}, tagName string) (tagIndex map[string][]int) {
+	tagIndex = GetSchema(reflect.TypeOf(item), tagName).index
 	return
 }
@@ -33,4 +33,29 @@ func Test_GetTagIndex(t *testing.T) {
 		}
 		return true
 	})
+
+	// 展开的嵌套结构体中的字段没有单一的位置
+	type Nested struct {
+		E int `quote:"eq"`
+	}
+	type NestedStruct struct {
+		Nested `quote:",prefix=n-"`
+		F      int `quote:"fq"`
+	}
+	assert.Equal(t, map[string]int{"fq": 1}, GetTagIndex(NestedStruct{}, "quote"))
+}
+
+func Test_GetTagIndexPath(t *testing.T) {
+	type Nested struct {
+		E int `quote:"eq"`
+	}
+	type TestStruct struct {
+		A      int `quote:"aq"`
+		Nested `quote:",prefix=n-"`
+		D      float32 `quote:""`
+	}
+
+	tagIndex := GetTagIndexPath(&TestStruct{}, "quote")
+
+	assert.Equal(t, map[string][]int{"aq": {0}, "n-eq": {1, 0}}, tagIndex)
 }
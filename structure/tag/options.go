@@ -0,0 +1,87 @@
+package tag
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Options tag 中名字之后的选项, 如 `excel:"入职日期,layout=2006-01-02"` 中的 layout
+//
+// 没有值的选项(如 `excel:"名字,required"`)对应空字符串
+type Options map[string]string
+
+// Get 获取选项的值
+func (o Options) Get(key string) (value string, ok bool) {
+	value, ok = o[key]
+	return
+}
+
+// Has 选项是否存在
+func (o Options) Has(key string) bool {
+	_, ok := o[key]
+	return ok
+}
+
+// RestOptionKey 值为 tag 剩余全部内容的选项, 其值可以包含逗号, 如 `excel:"身份证,required,regex=^[0-9]{6,18}$"`
+const RestOptionKey = "regex"
+
+// Parse 将 tag 拆分为名字与选项, 名字与选项之间, 选项与选项之间均以逗号分隔
+//
+// 名字包含逗号时需要用单引号包围, 如 `excel:"'金额(元,含税)',required"`.
+// RestOptionKey 选项必须是最后一个选项, 其值为之后的全部内容
+func Parse(tag string) (name string, opts Options) {
+	opts = make(Options)
+
+	name, rest := cutName(tag)
+	for rest != "" {
+		var part string
+		part, rest, _ = strings.Cut(rest, ",")
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		if key == RestOptionKey && rest != "" {
+			value += "," + rest
+			rest = ""
+		}
+		opts[key] = value
+	}
+
+	return
+}
+
+// ParseName 获取 tag 中的名字部分
+func ParseName(tag string) (name string) {
+	name, _ = cutName(tag)
+	return
+}
+
+// cutName 将 tag 拆分为名字与之后的选项部分, 以单引号包围的名字可以包含逗号, 缺少右引号时按普通的名字处理
+func cutName(tag string) (name string, rest string) {
+	if strings.HasPrefix(tag, "'") {
+		if end := strings.Index(tag[1:], "'"); end >= 0 {
+			name = tag[1 : end+1]
+			_, rest, _ = strings.Cut(tag[end+2:], ",")
+			return
+		}
+	}
+	name, rest, _ = strings.Cut(tag, ",")
+	return
+}
+
+// ParseAliases 将 tag 的名字部分按 "|" 拆分为别名列表, 忽略空的别名, 如 "手机号|Mobile" -> ["手机号", "Mobile"]
+func ParseAliases(name string) (aliases []string) {
+	for _, alias := range strings.Split(name, "|") {
+		if alias == "" {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return
+}
+
+// GetTagOptions 遍历所有字段, 获取指定 tagName 的所有 名字->选项 的映射, 返回值不可修改
+func GetTagOptions(item interface{}, tagName string) (tagOptions map[string]Options) {
+	tagOptions = GetSchema(reflect.TypeOf(item), tagName).options
+	return
+}
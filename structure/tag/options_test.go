@@ -0,0 +1,50 @@
+package tag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	name, opts := Parse("入职日期,layout=2006-01-02,required")
+	assert.Equal(t, "入职日期", name)
+	assert.Equal(t, Options{"layout": "2006-01-02", "required": ""}, opts)
+	assert.True(t, opts.Has("required"))
+	assert.False(t, opts.Has("omitempty"))
+
+	name, opts = Parse("名字")
+	assert.Equal(t, "名字", name)
+	assert.Empty(t, opts)
+
+	// regex 选项的值为之后的全部内容
+	name, opts = Parse(`身份证,required,regex=^\d{6,18}$,x`)
+	assert.Equal(t, "身份证", name)
+	assert.Equal(t, Options{"required": "", "regex": `^\d{6,18}$,x`}, opts)
+
+	// 单引号包围的名字可以包含逗号
+	name, opts = Parse("'金额(元,含税)|Amount',required")
+	assert.Equal(t, "金额(元,含税)|Amount", name)
+	assert.Equal(t, Options{"required": ""}, opts)
+	assert.Equal(t, "金额(元,含税)", ParseName("'金额(元,含税)'"))
+
+	// 缺少右引号时按普通的名字处理
+	name, opts = Parse("'金额,required")
+	assert.Equal(t, "'金额", name)
+	assert.Equal(t, Options{"required": ""}, opts)
+}
+
+func Test_GetTagOptions(t *testing.T) {
+	type TestStruct struct {
+		A int    `q:"a,min=1"`
+		B string `q:"b"`
+		C string `q:",x"`
+	}
+
+	tagOptions := GetTagOptions(TestStruct{}, "q")
+
+	assert.Equal(t, map[string]Options{
+		"a": {"min": "1"},
+		"b": {},
+	}, tagOptions)
+}
@@ -0,0 +1,106 @@
+package tag
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/yueja/go-excel-orm/structure"
+)
+
+// schemaCache 以 reflect.Type 为 key 缓存编译好的 Schema
+//
+// reflect.Type 唯一标识一个类型, 作用域内声明的同名结构体、匿名结构体与泛型实例化的结构体都不会互相冲突
+var schemaCache sync.Map
+
+type schemaKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+// Schema 结构体类型对于某个 tagName 编译后的字段信息, 同一类型只编译一次
+type Schema struct {
+	Type    reflect.Type       // 结构体类型
+	Fields  []Field            // 带有 tag 的字段, 顺序与结构体定义一致
+	names   []string           // 所有字段的 tag 名字
+	index   map[string][]int   // tag 名字 -> 位置路径
+	options map[string]Options // tag 名字 -> 选项
+	byName  map[string]int     // tag 名字 -> 在 Fields 中的位置
+}
+
+// GetSchema 获取类型 t 对于 tagName 的 Schema, t 会被尽力解引用
+func GetSchema(t reflect.Type, tagName string) (s *Schema) {
+	t = structure.TypeTry2Elem(t)
+	key := schemaKey{t: t, tagName: tagName}
+
+	// 从缓存拿 schema
+	sI, ok := schemaCache.Load(key)
+	if ok {
+		s = sI.(*Schema)
+		return
+	}
+
+	// 缓存不命中，新生成 schema
+	s = compileSchema(t, tagName)
+	sI, _ = schemaCache.LoadOrStore(key, s)
+	s = sI.(*Schema)
+	return
+}
+
+func compileSchema(t reflect.Type, tagName string) (s *Schema) {
+	fields := GetFields(t, tagName)
+
+	s = &Schema{
+		Type:    t,
+		Fields:  fields,
+		names:   make([]string, 0, len(fields)),
+		index:   make(map[string][]int, len(fields)),
+		options: make(map[string]Options, len(fields)),
+		byName:  make(map[string]int, len(fields)),
+	}
+	for i, field := range fields {
+		s.names = append(s.names, field.Name)
+		s.index[field.Name] = field.Index
+		s.options[field.Name] = field.Options
+		s.byName[field.Name] = i
+	}
+
+	return
+}
+
+// Names 获取所有字段的 tag 名字, 返回值不可修改
+func (s *Schema) Names() (names []string) {
+	return s.names
+}
+
+// Field 根据 tag 名字获取字段
+func (s *Schema) Field(name string) (field Field, ok bool) {
+	i, ok := s.byName[name]
+	if !ok {
+		return
+	}
+	field = s.Fields[i]
+	return
+}
+
+// Values 获取结构体 v 中所有字段的 tag->value 的映射, v 会被尽力解引用
+//
+// 展开的嵌套结构体指针为 nil 时, 其字段不会出现在结果中
+func (s *Schema) Values(v reflect.Value) (tag2Value map[string]interface{}) {
+	tag2Value = make(map[string]interface{}, len(s.Fields))
+
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	for _, field := range s.Fields {
+		fieldValue, err := v.FieldByIndexErr(field.Index)
+		if err != nil {
+			// 路径上存在 nil 指针
+			continue
+		}
+
+		tag2Value[field.Name] = fieldValue.Interface()
+	}
+
+	return
+}
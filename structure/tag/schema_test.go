@@ -0,0 +1,73 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type genericRow[T any] struct {
+	Value T `q:"value"`
+}
+
+func localRowA() reflect.Type {
+	type Row struct {
+		A int `q:"a"`
+	}
+	return reflect.TypeOf(Row{})
+}
+
+func localRowB() reflect.Type {
+	type Row struct {
+		B int `q:"b"`
+	}
+	return reflect.TypeOf(Row{})
+}
+
+func Test_GetSchema(t *testing.T) {
+	// 作用域内同名的结构体互不影响
+	assert.Equal(t, []string{"a"}, GetSchema(localRowA(), "q").Names())
+	assert.Equal(t, []string{"b"}, GetSchema(localRowB(), "q").Names())
+
+	// 匿名结构体
+	anonymous := struct {
+		C string `q:"c"`
+	}{}
+	assert.Equal(t, []string{"c"}, GetSchema(reflect.TypeOf(&anonymous), "q").Names())
+
+	// 泛型实例化的结构体
+	intSchema := GetSchema(reflect.TypeOf(genericRow[int]{}), "q")
+	strSchema := GetSchema(reflect.TypeOf([]genericRow[string]{}), "q")
+	assert.NotSame(t, intSchema, strSchema)
+	assert.Equal(t, reflect.TypeOf(0), intSchema.Fields[0].Field.Type)
+	assert.Equal(t, reflect.TypeOf(""), strSchema.Fields[0].Field.Type)
+
+	// 同一类型只编译一次
+	assert.Same(t, intSchema, GetSchema(reflect.TypeOf(&genericRow[int]{}), "q"))
+
+	field, ok := intSchema.Field("value")
+	if assert.True(t, ok) {
+		assert.Equal(t, []int{0}, field.Index)
+	}
+	_, ok = intSchema.Field("none")
+	assert.False(t, ok)
+}
+
+func Test_SchemaValues(t *testing.T) {
+	type Inner struct {
+		B int `q:"b"`
+	}
+	type Outer struct {
+		A string `q:"a"`
+		*Inner
+	}
+
+	schema := GetSchema(reflect.TypeOf(Outer{}), "q")
+	assert.Equal(t, map[string]interface{}{"a": "x"}, schema.Values(reflect.ValueOf(Outer{A: "x"})))
+	assert.Equal(
+		t,
+		map[string]interface{}{"a": "x", "b": 1},
+		schema.Values(reflect.ValueOf(&Outer{A: "x", Inner: &Inner{B: 1}})),
+	)
+}
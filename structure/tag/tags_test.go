@@ -18,3 +18,16 @@ func Test_GetTags(t *testing.T) {
 
 	assert.Equal(t, expected, tags)
 }
+
+func Test_GetTagsWithOptions(t *testing.T) {
+	type TestStructWithOptions struct {
+		A int    `q:"a,min=1"`
+		B bool   `q:"b,required"`
+		C string `q:"c"`
+	}
+
+	expected := []string{"a", "b", "c"}
+	tags := GetTags(TestStructWithOptions{}, "q")
+
+	assert.Equal(t, expected, tags)
+}
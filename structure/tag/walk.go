@@ -0,0 +1,106 @@
+package tag
+
+import (
+	"reflect"
+
+	"github.com/yueja/go-excel-orm/structure"
+)
+
+// Field 带有指定 tag 的字段
+type Field struct {
+	Name    string              // tag 名字, 即第一个别名, 已拼接所属嵌套结构体的前缀
+	Aliases []string            // 所有可接受的表头名字, 第一个与 Name 相同, 均已拼接前缀
+	Options Options             // tag 选项
+	Index   []int               // 字段在顶层结构体中的位置路径, 可用于 reflect.Value.FieldByIndex
+	Field   reflect.StructField // 字段本身
+}
+
+// GetFields 遍历所有字段, 获取带有指定 tagName 的字段列表
+//
+// 匿名嵌入且 tag 名字为空的结构体, 以及 tag 名字为空但带有 prefix 选项的结构体字段会被展开,
+// 其字段的 tag 名字会拼接 prefix 选项的值, 如 `excel:",prefix=收货地址-"`.
+// 嵌入结构体可以是指针. tag 名字可以用 "|" 分隔多个别名, 如 `excel:"手机号|Mobile"`, 第一个别名作为字段的名字.
+// 同名 tag 与 Go 的字段提升规则一致, 以嵌套层级最浅的字段为准, 层级相同时以第一个出现的字段为准.
+// 未导出的嵌入结构体指针无法通过反射分配, 不会被展开
+func GetFields(t reflect.Type, tagName string) (fields []Field) {
+	t = structure.TypeTry2Elem(t)
+	if t.Kind() != reflect.Struct || tagName == "" {
+		return
+	}
+
+	var all []Field
+	depths := make(map[string]int) // tag 名字 -> 最浅的层级
+	walkFields(t, tagName, "", nil, map[reflect.Type]bool{t: true}, func(field Field) {
+		depth, ok := depths[field.Name]
+		if !ok || len(field.Index) < depth {
+			depths[field.Name] = len(field.Index)
+		}
+		all = append(all, field)
+	})
+
+	seen := make(map[string]bool)
+	for _, field := range all {
+		if seen[field.Name] || len(field.Index) != depths[field.Name] {
+			continue
+		}
+		seen[field.Name] = true
+		fields = append(fields, field)
+	}
+
+	return
+}
+
+func walkFields(
+	t reflect.Type,
+	tagName string,
+	prefix string,
+	index []int,
+	visiting map[reflect.Type]bool, // 正在遍历的结构体类型, 防止自引用导致无限递归
+	fn func(field Field),
+) {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name, opts := Parse(structField.Tag.Get(tagName))
+
+		fieldIndex := make([]int, 0, len(index)+1)
+		fieldIndex = append(fieldIndex, index...)
+		fieldIndex = append(fieldIndex, i)
+
+		aliases := ParseAliases(name)
+		if len(aliases) == 0 {
+			// 需要展开的嵌套结构体
+			fieldPrefix, hasPrefix := opts.Get("prefix")
+			if !structField.Anonymous && !hasPrefix {
+				// tag 无值则无须导出
+				continue
+			}
+			fieldType := structField.Type
+			if fieldType.Kind() == reflect.Ptr {
+				if structField.PkgPath != "" {
+					// 未导出的结构体指针, 解码时无法分配
+					continue
+				}
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() != reflect.Struct || visiting[fieldType] {
+				continue
+			}
+
+			visiting[fieldType] = true
+			walkFields(fieldType, tagName, prefix+fieldPrefix, fieldIndex, visiting, fn)
+			delete(visiting, fieldType)
+			continue
+		}
+
+		for j := range aliases {
+			aliases[j] = prefix + aliases[j]
+		}
+		fn(Field{
+			Name:    aliases[0],
+			Aliases: aliases,
+			Options: opts,
+			Index:   fieldIndex,
+			Field:   structField,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetFields(t *testing.T) {
+	type Audit struct {
+		Creator string `q:"创建人"`
+		Ignored string
+	}
+	type Address struct {
+		City   string `q:"城市"`
+		Street string `q:"街道"`
+	}
+	type Node struct {
+		*Node
+		Value int `q:"值"`
+	}
+	type TestStruct struct {
+		Name string `q:"名字"`
+		Audit
+		Shipping Address  `q:",prefix=收货-"`
+		Billing  *Address `q:",prefix=账单-"`
+		Other    Address
+		Dup      string `q:"名字"`
+		Tree     Node   `q:","`
+	}
+
+	fields := GetFields(reflect.TypeOf(&TestStruct{}), "q")
+
+	names := make([]string, 0, len(fields))
+	indexes := make([][]int, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+		indexes = append(indexes, field.Index)
+	}
+	assert.Equal(t, []string{"名字", "创建人", "收货-城市", "收货-街道", "账单-城市", "账单-街道"}, names)
+	assert.Equal(t, [][]int{{0}, {1, 0}, {2, 0}, {2, 1}, {3, 0}, {3, 1}}, indexes)
+
+	// 自引用的嵌入结构体只展开一层
+	fields = GetFields(reflect.TypeOf(Node{}), "q")
+	if assert.Len(t, fields, 1) {
+		assert.Equal(t, []int{1}, fields[0].Index)
+	}
+}
+
+type audit4Walk struct {
+	Creator string `q:"创建人"`
+}
+
+func Test_GetFieldsShadowing(t *testing.T) {
+	type Base struct {
+		Name string `q:"名称"`
+		Code string `q:"编码"`
+	}
+	type TestStruct struct {
+		Base
+		Name string `q:"名称"`
+		*audit4Walk
+	}
+
+	// 外层字段覆盖嵌入结构体的同名字段, 未导出的嵌入结构体指针不展开
+	fields := GetFields(reflect.TypeOf(TestStruct{}), "q")
+	if assert.Len(t, fields, 2) {
+		assert.Equal(t, "编码", fields[0].Name)
+		assert.Equal(t, []int{0, 1}, fields[0].Index)
+		assert.Equal(t, "名称", fields[1].Name)
+		assert.Equal(t, []int{1}, fields[1].Index)
+	}
+}
+
+func Test_GetFieldsWithAliases(t *testing.T) {
+	type Contact struct {
+		Mobile string `q:"手机号|手机号码|Mobile"`
+	}
+	type TestStruct struct {
+		Name    string  `q:"名字||Name"`
+		Contact Contact `q:",prefix=联系人-"`
+	}
+
+	fields := GetFields(reflect.TypeOf(TestStruct{}), "q")
+	if assert.Len(t, fields, 2) {
+		assert.Equal(t, "名字", fields[0].Name)
+		assert.Equal(t, []string{"名字", "Name"}, fields[0].Aliases)
+		assert.Equal(t, "联系人-手机号", fields[1].Name)
+		assert.Equal(t, []string{"联系人-手机号", "联系人-手机号码", "联系人-Mobile"}, fields[1].Aliases)
+	}
+}
@@ -0,0 +1,391 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure"
+	"github.com/yueja/go-excel-orm/structure/tag"
+)
+
+const (
+	defaultDescriptionSheetName   = "说明"
+	defaultTemplateValidationRows = 1000
+	minTemplateColWidth           = 8  // 模板中列宽的下限
+	maxTemplateColWidth           = 50 // 模板中列宽的上限
+)
+
+// exampleTime 自动生成的示例行中时间字段的值
+var exampleTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TemplateOptions 导入模板的选项
+type TemplateOptions struct {
+	SheetName            string      // 数据所在的 sheet 名, 为空时为 Sheet1
+	DescriptionSheetName string      // 说明各列的 sheet 名, 为空时为 "说明"
+	Example              interface{} // 示例的元素, 为 nil 时根据字段的类型与 tag 自动生成
+	ValidationRows       int         // 数据有效性规则覆盖的行数, 不大于 0 时为 1000
+}
+
+// BuildTemplate 根据元素类型生成待填写的导入模板, elem 为目标元素或其指针, 只用于获取类型
+//
+// 需要多行表头或枚举时, 请使用 File.SetHeaders、File.RegisterEnum 后调用 File.WriteTemplate
+func BuildTemplate(elem interface{}, opts TemplateOptions) (ef *excelize.File, err error) {
+	f := NewFile()
+	err = f.WriteTemplate(elem, opts)
+	if err != nil {
+		return
+	}
+	ef = f.Export()
+	return
+}
+
+// WriteTemplate 根据元素类型写入待填写的导入模板, elem 为目标元素或其指针, 只用于获取类型
+//
+// 数据 sheet 只包含表头(优先使用 SetHeaders 设置的表头, 开启 SetGroupedHeaders 时生成分组表头)、根据表头与可选值估算的列宽、冻结的表头,
+// 以及与 Stream.SetValidationRows 相同的数据有效性规则; 说明 sheet 列出每一列的类型、是否必填、可选值、校验规则与示例.
+// 示例不写入数据 sheet, 未填写的模板解码后没有任何数据
+func (f *File) WriteTemplate(elem interface{}, opts TemplateOptions) (err error) {
+	t := structure.TypeTry2Elem(reflect.TypeOf(elem))
+	schema := getSchema(t)
+	if len(schema.Fields) == 0 {
+		err = errors.WithMessagef(ErrTagNotFound, "type: %s(%s)", t.String(), t.Kind().String())
+		err = errors.WithStack(err)
+		return
+	}
+
+	sheetName := opts.SheetName
+	if sheetName == "" {
+		sheetName = defaultSheetName
+	}
+	if f.ef.GetSheetIndex(sheetName) == -1 {
+		f.ef.NewSheet(sheetName)
+	}
+
+	// 流式写入器创建时即写入列宽与冻结窗格, 需要提前设置
+	headerRows := f.headersSet
+	if len(headerRows) == 0 {
+		headerRows = [][]string{schema.Names()}
+		if f.groupedHeaders {
+			headerRows = groupHeaderTexts(schema.Names())
+		}
+	}
+	err = f.setTemplateLayout(sheetName, schema, headerRows)
+	if err != nil {
+		return
+	}
+
+	s, err := f.Stream(sheetName)
+	if err != nil {
+		return
+	}
+	validationRows := opts.ValidationRows
+	if validationRows <= 0 {
+		validationRows = defaultTemplateValidationRows
+	}
+	s.SetValidationRows(validationRows)
+	err = s.writeHeader(t)
+	if err != nil {
+		return
+	}
+
+	// 示例写入说明 sheet, 避免被当作数据行解码
+	var example []interface{}
+	if opts.Example != nil {
+		example, err = s.buildRow(opts.Example)
+	} else {
+		example, err = s.exampleRow()
+	}
+	if err != nil {
+		return
+	}
+
+	err = s.Close()
+	if err != nil {
+		return
+	}
+
+	descriptionSheetName := opts.DescriptionSheetName
+	if descriptionSheetName == "" {
+		descriptionSheetName = defaultDescriptionSheetName
+	}
+	err = f.writeTemplateDescription(descriptionSheetName, schema, example)
+	return
+}
+
+// groupHeaderTexts 获取路径形式的表头拆分后的多行表头的文本, 被合并的单元格为空字符串
+func groupHeaderTexts(headers []string) (texts [][]string) {
+	rows, _ := groupHeaders(headers)
+	texts = make([][]string, 0, len(rows))
+	for _, row := range rows {
+		line := make([]string, 0, len(row))
+		for _, cell := range row {
+			text, _ := cell.(string)
+			line = append(line, text)
+		}
+		texts = append(texts, line)
+	}
+	return
+}
+
+// setTemplateLayout 设置模板的列宽, 并冻结表头
+func (f *File) setTemplateLayout(sheetName string, schema *tag.Schema, headerRows [][]string) (err error) {
+	for col, field := range schema.Fields {
+		width := minTemplateColWidth
+		for _, headerRow := range headerRows {
+			if col < len(headerRow) {
+				width = maxInt(width, textWidth(headerRow[col]))
+			}
+		}
+		for _, item := range f.fieldItems(field) {
+			width = maxInt(width, textWidth(item))
+		}
+		if width > maxTemplateColWidth {
+			width = maxTemplateColWidth
+		}
+
+		colName, _ := excelize.ColumnNumberToName(col + 1)
+		err = f.ef.SetColWidth(sheetName, colName, colName, float64(width))
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+
+	topLeftCell := cellName(0, len(headerRows))
+	err = f.ef.SetPanes(sheetName, fmt.Sprintf(
+		`{"freeze":true,"split":false,"x_split":0,"y_split":%d,"top_left_cell":"%s","active_pane":"bottomLeft"}`,
+		len(headerRows),
+		topLeftCell,
+	))
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
+// writeTemplateDescription 写入说明各列的 sheet, example 为每一列的示例值
+func (f *File) writeTemplateDescription(sheetName string, schema *tag.Schema, example []interface{}) (err error) {
+	if f.ef.GetSheetIndex(sheetName) == -1 {
+		f.ef.NewSheet(sheetName)
+	}
+
+	rows := [][]interface{}{{"列", "表头", "类型", "必填", "可选值", "说明", "示例"}}
+	styles := make(map[string]int) // 带有数字格式的示例单元格与其样式 ID
+	for col, field := range schema.Fields {
+		colName, _ := excelize.ColumnNumberToName(col + 1)
+		required := "否"
+		if field.Options.Has("required") {
+			required = "是"
+		}
+		var note string
+		note, err = f.fieldNote(field)
+		if err != nil {
+			return
+		}
+		var exampleCell interface{}
+		if col < len(example) {
+			exampleCell = example[col]
+		}
+		if cell, ok := exampleCell.(excelize.Cell); ok {
+			exampleCell = cell.Value
+			styles[cellName(6, len(rows))] = cell.StyleID
+		}
+		rows = append(rows, []interface{}{
+			colName,
+			field.Name,
+			typeDescription(field.Field.Type),
+			required,
+			strings.Join(f.fieldItems(field), "、"),
+			note,
+			exampleCell,
+		})
+	}
+
+	for i, row := range rows {
+		row := row
+		err = f.ef.SetSheetRow(sheetName, cellName(0, i), &row)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+	for axis, styleID := range styles {
+		err = f.ef.SetCellStyle(sheetName, axis, axis, styleID)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+	for col, width := range []float64{6, 20, 10, 6, 30, 40, 20} {
+		colName, _ := excelize.ColumnNumberToName(col + 1)
+		err = f.ef.SetColWidth(sheetName, colName, colName, width)
+		if err != nil {
+			err = errors.WithStack(err)
+			return
+		}
+	}
+	return
+}
+
+// fieldItems 获取字段的可选值, 来自 enum 或 oneof 选项, 没有时为 nil
+func (f *File) fieldItems(field tag.Field) (items []string) {
+	e, found, err := getEnum(f.enums, field.Options)
+	if found && err == nil {
+		items = e.labels
+		return
+	}
+	if s, ok := field.Options.Get("oneof"); ok {
+		items = strings.Fields(s)
+	}
+	return
+}
+
+// fieldNote 获取字段的校验规则与默认值的说明
+func (f *File) fieldNote(field tag.Field) (note string, err error) {
+	var notes []string
+	if len(f.fieldItems(field)) == 0 {
+		var msg string
+		_, msg, err = setDataValidationRule(excelize.NewDataValidation(true), field, f.enums, nil)
+		if err != nil {
+			err = errors.WithMessagef(err, "header: %s", field.Name)
+			err = errors.WithStack(err)
+			return
+		}
+		if msg != "" {
+			notes = append(notes, msg)
+		}
+	}
+	if layout, ok := field.Options.Get("layout"); ok {
+		notes = append(notes, "格式: "+layout)
+	}
+	if value, ok := field.Options.Get("default"); ok {
+		notes = append(notes, "默认值: "+value)
+	}
+	note = strings.Join(notes, "; ")
+	return
+}
+
+// exampleRow 根据字段的类型与 tag 生成每一列的示例值
+func (s *Stream) exampleRow() (row []interface{}, err error) {
+	row = make([]interface{}, 0, len(s.schema.Fields))
+	for _, field := range s.schema.Fields {
+		var cell interface{}
+		cell, err = s.exampleCell(field)
+		if err != nil {
+			err = errors.WithMessagef(err, "header: %s", field.Name)
+			return
+		}
+		row = append(row, cell)
+	}
+	return
+}
+
+// exampleCell 生成字段的示例值, 优先使用可选值与默认值
+func (s *Stream) exampleCell(field tag.Field) (cell interface{}, err error) {
+	opts := field.Options
+	e, found, err := getEnum(s.enums, opts)
+	if err != nil {
+		return
+	}
+	if found && len(e.labels) > 0 {
+		cell = e.labels[0]
+		return
+	}
+	if oneof, ok := opts.Get("oneof"); ok && len(strings.Fields(oneof)) > 0 {
+		cell = strings.Fields(oneof)[0]
+		return
+	}
+	defaultValue, hasDefault := opts.Get("default")
+
+	t := field.Field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		cell, err = s.styleTimeValue(exampleTime, opts)
+		return
+	}
+	if hasDefault {
+		cell = defaultValue
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		var n float64
+		for _, key := range []string{"min", "max"} {
+			if v, ok := opts.Get(key); ok {
+				n, _ = strconv.ParseFloat(v, 64)
+				break
+			}
+		}
+		cell = n
+	case reflect.Bool:
+		cell = true
+	case reflect.String:
+		cell = "示例"
+		for _, key := range []string{"len", "min"} {
+			if v, ok := opts.Get(key); ok {
+				n, _ := strconv.Atoi(v)
+				if n > 0 {
+					cell = strings.Repeat("x", n)
+				}
+				break
+			}
+		}
+	}
+	return
+}
+
+// typeDescription 获取类型在说明中的名字
+func typeDescription(t reflect.Type) (desc string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return "日期时间"
+	case t == durationType:
+		return "时长"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "整数"
+	case reflect.Float32, reflect.Float64:
+		return "小数"
+	case reflect.Bool:
+		return "布尔"
+	case reflect.String:
+		return "文本"
+	}
+	return t.String()
+}
+
+// textWidth 估算文本在 excel 中的显示宽度, 非 ASCII 字符按两个字符计算
+func textWidth(s string) (width int) {
+	for _, r := range s {
+		if utf8.RuneLen(r) > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width + 2
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
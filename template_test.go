@@ -0,0 +1,168 @@
+package excel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer4Template struct {
+	Name     string    `excel:"联系人/姓名,required,max=20"`
+	Phone    string    `excel:"联系人/电话,len=11"`
+	Gender   int       `excel:"性别,enum=gender"`
+	Level    string    `excel:"等级,oneof=A B C"`
+	Age      *int      `excel:"年龄,min=18"`
+	Birthday time.Time `excel:"生日,layout=2006-01-02"`
+	Remark   string    `excel:"备注,default=无"`
+}
+
+func Test_BuildTemplate(t *testing.T) {
+	f := NewFile()
+	f.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+	f.SetGroupedHeaders(true)
+	if !assert.NoError(t, f.WriteTemplate(&Customer4Template{}, TemplateOptions{ValidationRows: 10})) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 冻结的表头与数据有效性
+	var sheet struct {
+		Pane struct {
+			YSplit      int    `xml:"ySplit,attr"`
+			TopLeftCell string `xml:"topLeftCell,attr"`
+			State       string `xml:"state,attr"`
+		} `xml:"sheetViews>sheetView>pane"`
+	}
+	readSheetXML(t, buf, &sheet)
+	assert.Equal(t, 2, sheet.Pane.YSplit)
+	assert.Equal(t, "A3", sheet.Pane.TopLeftCell)
+	assert.Equal(t, "frozen", sheet.Pane.State)
+	dvs := readDataValidations(t, buf).DataValidations
+	if assert.Len(t, dvs, 6) {
+		assert.Equal(t, "A3:A12", dvs[0].Sqref)
+	}
+
+	r, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := r.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) && assert.Len(t, rows, 2) {
+		assert.Equal(t, []string{"联系人", "", "性别", "等级", "年龄", "生日", "备注"}, rows[0])
+		assert.Equal(t, []string{"姓名", "电话", "", "", "", "", ""}, rows[1])
+	}
+
+	// 未填写的模板没有任何数据
+	r.RegisterEnum("gender", map[string]interface{}{"男": 1, "女": 2})
+	r.SetHeaderRowCount(2)
+	var decoded []Customer4Template
+	if assert.NoError(t, r.Decode(&decoded)) {
+		assert.Empty(t, decoded)
+	}
+
+	// 说明与示例
+	rows, err = r.Export().GetRows(defaultDescriptionSheetName)
+	if assert.NoError(t, err) && assert.Len(t, rows, 8) {
+		assert.Equal(t, []string{"列", "表头", "类型", "必填", "可选值", "说明", "示例"}, rows[0])
+		assert.Equal(t, []string{"A", "联系人/姓名", "文本", "是", "", "请输入至多 20 个字符", "示例"}, rows[1])
+		assert.Equal(t, []string{"B", "联系人/电话", "文本", "否", "", "请输入 11 到 11 个字符", "xxxxxxxxxxx"}, rows[2])
+		assert.Equal(t, []string{"C", "性别", "整数", "否", "女、男", "", "女"}, rows[3])
+		assert.Equal(t, []string{"E", "年龄", "整数", "否", "", "请输入不小于 18 的整数", "18"}, rows[5])
+		assert.Equal(t, []string{"F", "生日", "日期时间", "否", "", "请输入日期; 格式: 2006-01-02", "2021-01-01"}, rows[6])
+		assert.Equal(t, []string{"G", "备注", "文本", "否", "", "默认值: 无", "无"}, rows[7])
+	}
+
+	// 列宽
+	width, err := r.Export().GetColWidth(defaultSheetName, "A")
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(minTemplateColWidth), width)
+	}
+	width, err = r.Export().GetColWidth(defaultSheetName, "F")
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(minTemplateColWidth), width)
+	}
+}
+
+func Test_BuildTemplateWithHeadersAndExample(t *testing.T) {
+	type Customer4TemplateExample struct {
+		Name string `excel:"name"`
+		Age  int    `excel:"age"`
+	}
+
+	f := NewFile()
+	f.SetHeaders([][]string{{"客户信息", ""}, {"name", "age"}})
+	err := f.WriteTemplate(Customer4TemplateExample{}, TemplateOptions{
+		SheetName:            "客户",
+		DescriptionSheetName: "填写说明",
+		Example:              Customer4TemplateExample{Name: "张三", Age: 30},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := r.Export().GetRows("客户")
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{{"客户信息", ""}, {"name", "age"}}, rows)
+	}
+	rows, err = r.Export().GetRows("填写说明")
+	if assert.NoError(t, err) && assert.Len(t, rows, 3) {
+		assert.Equal(t, "张三", rows[1][6])
+		assert.Equal(t, "30", rows[2][6])
+	}
+
+	// 没有表头的类型
+	_, err = BuildTemplate(struct{ Name string }{}, TemplateOptions{})
+	assert.True(t, errors.Is(err, ErrTagNotFound))
+}
+
+func Test_BuildTemplateWithLargeEnum(t *testing.T) {
+	type Customer4TemplateLargeEnum struct {
+		Name     string `excel:"name"`
+		Province int    `excel:"province,required,enum=province"`
+	}
+	provinces := make(map[string]interface{}, 60)
+	for i := 1; i <= 60; i++ {
+		provinces[fmt.Sprintf("省份%02d", i)] = i
+	}
+
+	f := NewFile()
+	f.RegisterEnum("province", provinces)
+	if !assert.NoError(t, f.WriteTemplate(&Customer4TemplateLargeEnum{}, TemplateOptions{})) {
+		return
+	}
+	buf, err := f.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 下拉列表引用隐藏 sheet 中的区域
+	dvs := readDataValidations(t, buf).DataValidations
+	if assert.Len(t, dvs, 1) {
+		assert.Equal(t, "B2:B1001", dvs[0].Sqref)
+		assert.Equal(t, "'_droplists'!$A$1:$A$60", dvs[0].Formula1)
+	}
+
+	r, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := r.Export().GetRows(defaultDescriptionSheetName)
+	if assert.NoError(t, err) && assert.Len(t, rows, 3) {
+		assert.Equal(t, []string{"B", "province", "整数", "是"}, rows[2][:4])
+		assert.Len(t, strings.Split(rows[2][4], "、"), 60)
+	}
+}
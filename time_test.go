@@ -0,0 +1,67 @@
+package excel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteAndDecodeTime(t *testing.T) {
+	type Employee4Time struct {
+		Name     string        `excel:"name"`
+		JoinedAt time.Time     `excel:"joined_at"`
+		Birthday time.Time     `excel:"birthday,layout=2006-01-02"`
+		Shift    time.Duration `excel:"shift"`
+		LeftAt   *time.Time    `excel:"left_at,layout=2006/01/02"`
+	}
+	leftAt := time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)
+	es := []Employee4Time{
+		{
+			Name:     "a",
+			JoinedAt: time.Date(2023, 1, 1, 9, 30, 0, 0, time.UTC),
+			Birthday: time.Date(1990, 5, 20, 0, 0, 0, 0, time.UTC),
+			Shift:    8*time.Hour + 30*time.Minute,
+			LeftAt:   &leftAt,
+		},
+		{
+			Name:     "b",
+			JoinedAt: time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC),
+		},
+	}
+
+	ef, err := BuildFile(es)
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf, err := ef.WriteToBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err := OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, [][]string{
+		{"name", "joined_at", "birthday", "shift", "left_at"},
+		{"a", "2023-01-01 09:30:00", "1990-05-20", "8h30m0s", "2023/06/30"},
+		{"b", "2024-02-29 18:00:00", "", "0s", ""},
+	}, rows)
+
+	var decoded []Employee4Time
+	err = f.Decode(&decoded)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, es, decoded)
+}
+
+func Test_layout2NumFmt(t *testing.T) {
+	assert.Equal(t, "yyyy-mm-dd hh:mm:ss", layout2NumFmt("2006-01-02 15:04:05"))
+	assert.Equal(t, "yyyy年m月d日", layout2NumFmt("2006年1月2日"))
+	assert.Equal(t, "yy/mm/dd", layout2NumFmt("06/01/02"))
+}
@@ -1,20 +1,53 @@
 package excel
 
-import "github.com/yueja/go-excel-orm/structure/tag"
+import (
+	"reflect"
 
-func getTags(elem interface{}) (headers []string) {
-	headers = tag.GetTags(elem, "excel")
-	return
+	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure/tag"
+)
+
+// excelTagName 标记表头的 tag 名
+const excelTagName = "excel"
+
+// knownTagOptions 所有支持的 excel tag 选项
+var knownTagOptions = map[string]bool{
+	"prefix":    true,
+	"layout":    true,
+	"required":  true,
+	"default":   true,
+	"omitempty": true,
+	"empty":     true,
+	"enum":      true,
+	"min":       true,
+	"max":       true,
+	"len":       true,
+	"regex":     true,
+	"oneof":     true,
+	"email":     true,
+	"phone":     true,
 }
 
-// buildTagIndex 获取 excel tag 在结构体中的位置
-func buildTagIndex(item interface{}) (tagIndex map[string]int) {
-	tagIndex = tag.GetTagIndex(item, "excel")
+// checkTagOptions 检查字段的 tag 选项是否均被支持, 避免拼写错误的选项或未用引号包围的含逗号的表头被静默忽略
+func checkTagOptions(field tag.Field) (err error) {
+	for key := range field.Options {
+		if !knownTagOptions[key] {
+			err = errors.WithMessagef(
+				ErrTagOptionInvalid,
+				"header: %s, unknown option: %s, header containing commas must be quoted, e.g. 'a,b'",
+				field.Name,
+				key,
+			)
+			err = errors.WithStack(err)
+			return
+		}
+	}
 	return
 }
 
-func getHeader2Value(elem interface{}) (header2value map[string]interface{}) {
-	header2value = tag.GetTag2Value(elem, "excel")
+// getSchema 获取类型的 excel tag Schema, t 会被尽力解引用
+func getSchema(t reflect.Type) (s *tag.Schema) {
+	s = tag.GetSchema(t, excelTagName)
 	return
 }
 
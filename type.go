@@ -2,10 +2,16 @@ package excel
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
 // getElemTypeOfElem 获取目标变量的类型
 func getElemTypeOfElem(elemPtr interface{}) (elemType reflect.Type, err error) {
 	elemPtrType := reflect.TypeOf(elemPtr)
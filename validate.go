@@ -0,0 +1,204 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/pkg/errors"
+	"github.com/yueja/go-excel-orm/structure/tag"
+)
+
+// Validator 元素类型实现此接口时, 每行的所有字段解析且校验成功后调用一次 Validate, 用于跨字段的校验
+//
+// 返回 *DecodeError 时可以通过 Header 指定出错的字段, 其 Sheet、Row、Col 会被自动填充
+type Validator interface {
+	Validate() error
+}
+
+var (
+	emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`) // 中国大陆手机号
+)
+
+// fieldValidator 字段校验器, value 为解析后的字段值, 指针已被解引用
+type fieldValidator func(value reflect.Value) error
+
+// compileValidators 根据 tag 选项编译字段的校验器
+//
+// 支持的选项:
+//   - min=n, max=n: 数值的范围, 字符串的字符数或切片的长度的范围
+//   - len=n: 字符串的字符数或切片的长度, 用于数值字段时返回 ErrTagOptionInvalid
+//   - regex=表达式: 字符串匹配正则表达式, 必须是最后一个选项, 表达式为之后的全部内容, 可以包含逗号
+//   - oneof=a b c: 值的字符串形式为以空格分隔的候选值之一
+//   - email: 字符串为邮箱地址
+//   - phone: 字符串为中国大陆手机号
+func compileValidators(field tag.Field) (validators []fieldValidator, err error) {
+	opts := field.Options
+	t := field.Field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, key := range []string{"min", "max", "len"} {
+		s, ok := opts.Get(key)
+		if !ok {
+			continue
+		}
+		var n float64
+		n, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "header: %s, %s=%s", field.Name, key, s)
+			err = errors.WithStack(err)
+			return
+		}
+		if key == "len" && isNumberKind(t.Kind()) {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "header: %s, len=%s: len only applies to strings, slices and maps", field.Name, s)
+			err = errors.WithStack(err)
+			return
+		}
+		validators = append(validators, sizeValidator(key, n))
+	}
+
+	if s, ok := opts.Get("regex"); ok {
+		var re *regexp.Regexp
+		re, err = regexp.Compile(s)
+		if err != nil {
+			err = errors.WithMessagef(ErrTagOptionInvalid, "header: %s, regex=%s: %v", field.Name, s, err)
+			err = errors.WithStack(err)
+			return
+		}
+		validators = append(validators, regexpValidator("regex", re))
+	}
+	if opts.Has("email") {
+		validators = append(validators, regexpValidator("email", emailRegexp))
+	}
+	if opts.Has("phone") {
+		validators = append(validators, regexpValidator("phone", phoneRegexp))
+	}
+
+	if s, ok := opts.Get("oneof"); ok {
+		validators = append(validators, oneofValidator(strings.Fields(s)))
+	}
+
+	return
+}
+
+// sizeValidator 生成 min、max、len 选项的校验器
+func sizeValidator(key string, n float64) (validator fieldValidator) {
+	validator = func(value reflect.Value) (err error) {
+		var size float64
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			size = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			size = value.Float()
+		case reflect.String:
+			size = float64(utf8.RuneCountInString(value.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			size = float64(value.Len())
+		default:
+			return
+		}
+
+		var ok bool
+		switch key {
+		case "min":
+			ok = size >= n
+		case "max":
+			ok = size <= n
+		default:
+			ok = size == n
+		}
+		if !ok {
+			err = errors.WithMessagef(ErrValidationFailed, "%s=%v, but %v", key, n, size)
+			err = errors.WithStack(err)
+		}
+		return
+	}
+	return
+}
+
+// regexpValidator 生成字符串匹配正则表达式的校验器, 非字符串类型的字段不校验
+func regexpValidator(key string, re *regexp.Regexp) (validator fieldValidator) {
+	validator = func(value reflect.Value) (err error) {
+		if value.Kind() != reflect.String {
+			return
+		}
+		if !re.MatchString(value.String()) {
+			err = errors.WithMessagef(ErrValidationFailed, "%s: %s", key, re.String())
+			err = errors.WithStack(err)
+		}
+		return
+	}
+	return
+}
+
+// oneofValidator 生成候选值的校验器, 比较值的字符串形式
+func oneofValidator(candidates []string) (validator fieldValidator) {
+	validator = func(value reflect.Value) (err error) {
+		s := fmt.Sprint(value.Interface())
+		for _, candidate := range candidates {
+			if s == candidate {
+				return
+			}
+		}
+		err = errors.WithMessagef(ErrValidationFailed, "oneof: %s", strings.Join(candidates, " "))
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
+// validateField 使用字段的所有校验器校验 value, 返回第一个错误
+func validateField(validators []fieldValidator, value reflect.Value) (err error) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	for _, validator := range validators {
+		err = validator(value)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// validateElem 元素实现了 Validator 时调用其 Validate, 并将错误转换为第 rowNow 个数据行的解码错误
+func (c *Cursor) validateElem(elemPtr reflect.Value, rowNow int) (errs DecodeErrors) {
+	validator, ok := elemPtr.Interface().(Validator)
+	if !ok {
+		return
+	}
+	err := validator.Validate()
+	if err == nil {
+		return
+	}
+
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		errs = append(errs, c.newDecodeError("", "", -1, rowNow, err))
+		return
+	}
+
+	// 填充用户返回的解码错误的位置
+	filled := *de
+	filled.Sheet = c.sheetName
+	filled.Row = rowNow + c.rowOffset
+	if filled.Col == "" && filled.Header != "" {
+		if col, _ := c.findCol([]string{filled.Header}); col >= 0 {
+			filled.Col, _ = excelize.ColumnNumberToName(col + 1)
+		}
+	}
+	errs = append(errs, &filled)
+	return
+}
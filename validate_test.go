@@ -0,0 +1,159 @@
+package excel
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer4Validate struct {
+	Name   string  `excel:"name,min=2,max=4"`
+	Age    int     `excel:"age,min=18,max=60"`
+	Code   string  `excel:"code,len=3,regex=^[A-Z]+$"`
+	Gender string  `excel:"gender,oneof=男 女"`
+	Level  *int    `excel:"level,oneof=1 2 3"`
+	Email  string  `excel:"email,email,omitempty"`
+	Mobile string  `excel:"mobile,phone"`
+	Start  int     `excel:"start"`
+	End    int     `excel:"end"`
+	Rate   float64 `excel:"rate,max=1"`
+}
+
+// Validate 实现 Validator 接口
+func (c *Customer4Validate) Validate() error {
+	if c.End < c.Start {
+		return &DecodeError{Header: "end", Value: "", Err: errors.New("end before start")}
+	}
+	return nil
+}
+
+func Test_DecodeWithValidation(t *testing.T) {
+	headers := []interface{}{"name", "age", "code", "gender", "level", "email", "mobile", "start", "end", "rate"}
+	f := openTestFile(t, [][]interface{}{
+		headers,
+		{"张三", 20, "ABC", "男", 1, "a@b.com", "13800138000", 1, 2, 0.5},
+		{"张", 17, "AB1", "未知", 4, "ab.com", "12345", 1, 2, 1.5},
+		{"李四", 30, "XYZ", "女", nil, "", "13900139000", 3, 2, 1},
+	})
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+
+	var customers []Customer4Validate
+	err := f.Decode(&customers)
+	if assert.Len(t, customers, 1) {
+		assert.Equal(t, "张三", customers[0].Name)
+	}
+
+	var decodeErrs DecodeErrors
+	if !assert.True(t, errors.As(err, &decodeErrs)) {
+		return
+	}
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	cells := make([]string, 0, len(decodeErrs))
+	for _, de := range decodeErrs {
+		cells = append(cells, fmt.Sprintf("%s%d", de.Col, de.Row))
+	}
+	// 第 3 行每个违反规则的字段各一个错误, 第 4 行为跨字段校验的错误
+	assert.Equal(t, []string{"A3", "B3", "C3", "D3", "E3", "F3", "G3", "J3", "I4"}, cells)
+	assert.EqualError(t, errors.Cause(decodeErrs[8].Err), "end before start")
+	assert.Equal(t, defaultSheetName, decodeErrs[8].Sheet)
+
+	// 遇到第一个错误立即停止
+	f.SetErrorPolicy(ErrorPolicyFailFast)
+	err = f.Decode(&customers)
+	if assert.True(t, errors.As(err, &decodeErrs)) {
+		assert.Len(t, decodeErrs, 1)
+	}
+
+	// 非法的校验规则
+	type Customer4InvalidRule struct {
+		Name string `excel:"name,regex=[a-"`
+	}
+	var invalid []Customer4InvalidRule
+	err = f.Decode(&invalid)
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+
+	// len 只用于字符串、切片与 map 的长度
+	type Customer4NumericLen struct {
+		Age *int `excel:"age,len=3"`
+	}
+	var numericLen []Customer4NumericLen
+	err = f.Decode(&numericLen)
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+	assert.Contains(t, err.Error(), "len only applies to strings, slices and maps")
+}
+
+func Test_DecodeWithValidationKeepZero(t *testing.T) {
+	type Customer4KeepZero struct {
+		Name  string `excel:"name"`
+		Age   int    `excel:"age,max=150"`
+		Level *int   `excel:"level,oneof=1 2 3"`
+	}
+	f := openTestFile(t, [][]interface{}{{"name", "age", "level"}, {"a", 200, 4}, {"b", 20, 2}})
+	f.SetErrorPolicy(ErrorPolicyKeepZero)
+
+	// 校验失败的字段保留零值
+	var customers []Customer4KeepZero
+	err := f.Decode(&customers)
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	level := 2
+	assert.Equal(t, []Customer4KeepZero{{Name: "a"}, {Name: "b", Age: 20, Level: &level}}, customers)
+}
+
+func Test_TagOptions(t *testing.T) {
+	// regex 选项为最后一个选项时, 表达式可以包含逗号
+	type Customer4Regex struct {
+		IDCard string `excel:"id,required,regex=^[0-9]{6,18}$"`
+	}
+	f := openTestFile(t, [][]interface{}{{"id"}, {"123456"}, {"12345"}})
+	f.SetErrorPolicy(ErrorPolicySkipRow)
+	var customers []Customer4Regex
+	err := f.Decode(&customers)
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	assert.Equal(t, []Customer4Regex{{IDCard: "123456"}}, customers)
+
+	// 不支持的选项
+	type Customer4UnknownOption struct {
+		Name string `excel:"name,requird"`
+	}
+	var unknown []Customer4UnknownOption
+	err = f.Decode(&unknown)
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+
+	ef, err := BuildFile([]Customer4UnknownOption{{Name: "a"}})
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+	assert.Nil(t, ef)
+
+	// 包含逗号的表头需要用单引号包围, 否则逗号之后的内容被当作选项
+	type Invoice4UnquotedComma struct {
+		Amount float64 `excel:"金额(元,含税)"`
+	}
+	_, err = BuildFile([]Invoice4UnquotedComma{{Amount: 1.5}})
+	assert.True(t, errors.Is(err, ErrTagOptionInvalid))
+
+	type Invoice4QuotedComma struct {
+		Amount float64 `excel:"'金额(元,含税)|Amount',required"`
+	}
+	invoices := []Invoice4QuotedComma{{Amount: 1.5}}
+	w := NewFile()
+	if !assert.NoError(t, w.Write(invoices)) {
+		return
+	}
+	buf, err := w.ExportBuffer()
+	if !assert.NoError(t, err) {
+		return
+	}
+	f, err = OpenReader(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rows, err := f.Export().GetRows(defaultSheetName)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]string{{"金额(元,含税)"}, {"1.5"}}, rows)
+	}
+	var decoded []Invoice4QuotedComma
+	if assert.NoError(t, f.Decode(&decoded)) {
+		assert.Equal(t, invoices, decoded)
+	}
+}